@@ -16,32 +16,122 @@
 package jwt
 
 import (
-	"crypto"
-	"crypto/hmac"
-	"crypto/sha256"
-	"crypto/sha512"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 )
 
 //HmacSha256 returns the SingingMethod for HMAC with SHA256
 func HmacSha256(key string) JWT {
-	return JWT{
-		algorithm:   "HS256",
-		signingHash: hmac.New(sha256.New, []byte(key)),
-	}
+	return New(SigningMethodHS256, []byte(key), []byte(key))
 }
 
 //HmacSha512 returns the SigningMethod for HMAC with SHA512
 func HmacSha512(key string) JWT {
-	return JWT{
-		algorithm:   "HS512",
-		signingHash: hmac.New(sha512.New, []byte(key)),
-	}
+	return New(SigningMethodHS512, []byte(key), []byte(key))
 }
 
 //HmacSha384 returns the SigningMethod for HMAC with SHA384
 func HmacSha384(key string) JWT {
-	return JWT{
-		algorithm:   "HS384",
-		signingHash: hmac.New(crypto.SHA384.New, []byte(key)),
-	}
+	return New(SigningMethodHS384, []byte(key), []byte(key))
+}
+
+//RsaSha256 returns the SigningMethod for RSASSA-PKCS1-v1_5 with SHA256
+func RsaSha256(key *rsa.PrivateKey) JWT {
+	return New(SigningMethodRS256, key, rsaPublicKey(key))
+}
+
+//RsaSha384 returns the SigningMethod for RSASSA-PKCS1-v1_5 with SHA384
+func RsaSha384(key *rsa.PrivateKey) JWT {
+	return New(SigningMethodRS384, key, rsaPublicKey(key))
+}
+
+//RsaSha512 returns the SigningMethod for RSASSA-PKCS1-v1_5 with SHA512
+func RsaSha512(key *rsa.PrivateKey) JWT {
+	return New(SigningMethodRS512, key, rsaPublicKey(key))
+}
+
+//RsaSha256Verifier returns the verification-only SigningMethod for RSASSA-PKCS1-v1_5 with SHA256
+func RsaSha256Verifier(key *rsa.PublicKey) JWT {
+	return New(SigningMethodRS256, nil, key)
+}
+
+//RsaSha384Verifier returns the verification-only SigningMethod for RSASSA-PKCS1-v1_5 with SHA384
+func RsaSha384Verifier(key *rsa.PublicKey) JWT {
+	return New(SigningMethodRS384, nil, key)
+}
+
+//RsaSha512Verifier returns the verification-only SigningMethod for RSASSA-PKCS1-v1_5 with SHA512
+func RsaSha512Verifier(key *rsa.PublicKey) JWT {
+	return New(SigningMethodRS512, nil, key)
+}
+
+//PsSha256 returns the SigningMethod for RSASSA-PSS with SHA256
+func PsSha256(key *rsa.PrivateKey) JWT {
+	return New(SigningMethodPS256, key, rsaPublicKey(key))
+}
+
+//PsSha384 returns the SigningMethod for RSASSA-PSS with SHA384
+func PsSha384(key *rsa.PrivateKey) JWT {
+	return New(SigningMethodPS384, key, rsaPublicKey(key))
+}
+
+//PsSha512 returns the SigningMethod for RSASSA-PSS with SHA512
+func PsSha512(key *rsa.PrivateKey) JWT {
+	return New(SigningMethodPS512, key, rsaPublicKey(key))
+}
+
+//PsSha256Verifier returns the verification-only SigningMethod for RSASSA-PSS with SHA256
+func PsSha256Verifier(key *rsa.PublicKey) JWT {
+	return New(SigningMethodPS256, nil, key)
+}
+
+//PsSha384Verifier returns the verification-only SigningMethod for RSASSA-PSS with SHA384
+func PsSha384Verifier(key *rsa.PublicKey) JWT {
+	return New(SigningMethodPS384, nil, key)
+}
+
+//PsSha512Verifier returns the verification-only SigningMethod for RSASSA-PSS with SHA512
+func PsSha512Verifier(key *rsa.PublicKey) JWT {
+	return New(SigningMethodPS512, nil, key)
+}
+
+//EsSha256 returns the SigningMethod for ECDSA using P-256 and SHA256
+func EsSha256(key *ecdsa.PrivateKey) JWT {
+	return New(SigningMethodES256, key, ecdsaPublicKey(key))
+}
+
+//EsSha384 returns the SigningMethod for ECDSA using P-384 and SHA384
+func EsSha384(key *ecdsa.PrivateKey) JWT {
+	return New(SigningMethodES384, key, ecdsaPublicKey(key))
+}
+
+//EsSha512 returns the SigningMethod for ECDSA using P-521 and SHA512
+func EsSha512(key *ecdsa.PrivateKey) JWT {
+	return New(SigningMethodES512, key, ecdsaPublicKey(key))
+}
+
+//EsSha256Verifier returns the verification-only SigningMethod for ECDSA using P-256 and SHA256
+func EsSha256Verifier(key *ecdsa.PublicKey) JWT {
+	return New(SigningMethodES256, nil, key)
+}
+
+//EsSha384Verifier returns the verification-only SigningMethod for ECDSA using P-384 and SHA384
+func EsSha384Verifier(key *ecdsa.PublicKey) JWT {
+	return New(SigningMethodES384, nil, key)
+}
+
+//EsSha512Verifier returns the verification-only SigningMethod for ECDSA using P-521 and SHA512
+func EsSha512Verifier(key *ecdsa.PublicKey) JWT {
+	return New(SigningMethodES512, nil, key)
+}
+
+//Ed25519 returns the SigningMethod for EdDSA using Ed25519
+func Ed25519(key ed25519.PrivateKey) JWT {
+	return New(SigningMethodEdDSA, key, ed25519PublicKey(key))
+}
+
+//Ed25519Verifier returns the verification-only SigningMethod for EdDSA using Ed25519
+func Ed25519Verifier(key ed25519.PublicKey) JWT {
+	return New(SigningMethodEdDSA, nil, key)
 }
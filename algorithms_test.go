@@ -0,0 +1,229 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+var testRSAKey *rsa.PrivateKey
+
+func rsaTestKey(t *testing.T) *rsa.PrivateKey {
+	if testRSAKey == nil {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("jwt.rsaTestKey: %s", err)
+		}
+		testRSAKey = key
+	}
+	return testRSAKey
+}
+
+func TestRsa_EncodeAndValidate(t *testing.T) {
+	key := rsaTestKey(t)
+	var data = []struct {
+		signer   JWT
+		verifier JWT
+		alg      string
+	}{
+		{signer: RsaSha256(key), verifier: RsaSha256Verifier(&key.PublicKey), alg: "RS256"},
+		{signer: RsaSha384(key), verifier: RsaSha384Verifier(&key.PublicKey), alg: "RS384"},
+		{signer: RsaSha512(key), verifier: RsaSha512Verifier(&key.PublicKey), alg: "RS512"},
+	}
+	for _, d := range data {
+		claims := NewClaims()
+		claims.Set("sub", "user")
+		encoded, err := d.signer.Encode(claims)
+		if err != nil {
+			t.Errorf("jwt.TestRsa_EncodeAndValidate, %s: %s", d.alg, err)
+			continue
+		}
+		if header := d.signer.NewHeader(); header.Alg != d.alg {
+			t.Errorf("jwt.TestRsa_EncodeAndValidate, %s: invalid Alg: %s", d.alg, header.Alg)
+		}
+		if err := d.signer.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestRsa_EncodeAndValidate, %s: signer: %s", d.alg, err)
+		}
+		if err := d.verifier.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestRsa_EncodeAndValidate, %s: verifier: %s", d.alg, err)
+		}
+	}
+}
+
+func TestRsa_ValidateTampered(t *testing.T) {
+	key := rsaTestKey(t)
+	signer := RsaSha256(key)
+	encoded, err := signer.Encode(NewClaims())
+	if err != nil {
+		t.Fatalf("jwt.TestRsa_ValidateTampered: %s", err)
+	}
+	segments := strings.Split(encoded, ".")
+	claims := NewClaims()
+	claims.Set("admin", true)
+	forged, _ := signer.Encode(claims)
+	tampered := segments[0] + "." + strings.Split(forged, ".")[1] + "." + segments[2]
+	verifier := RsaSha256Verifier(&key.PublicKey)
+	if err := verifier.Validate(tampered); err == nil {
+		t.Errorf("jwt.TestRsa_ValidateTampered: tampered token is valid")
+	}
+}
+
+func TestRsa_VerifierCanNotSign(t *testing.T) {
+	verifier := RsaSha256Verifier(&rsaTestKey(t).PublicKey)
+	if _, err := verifier.Encode(NewClaims()); err != ErrTokenUnableToSign {
+		t.Errorf("jwt.TestRsa_VerifierCanNotSign: %v != %v", err, ErrTokenUnableToSign)
+	}
+}
+
+func TestPs_EncodeAndValidate(t *testing.T) {
+	key := rsaTestKey(t)
+	var data = []struct {
+		signer   JWT
+		verifier JWT
+		alg      string
+	}{
+		{signer: PsSha256(key), verifier: PsSha256Verifier(&key.PublicKey), alg: "PS256"},
+		{signer: PsSha384(key), verifier: PsSha384Verifier(&key.PublicKey), alg: "PS384"},
+		{signer: PsSha512(key), verifier: PsSha512Verifier(&key.PublicKey), alg: "PS512"},
+	}
+	for _, d := range data {
+		encoded, err := d.signer.Encode(NewClaims())
+		if err != nil {
+			t.Errorf("jwt.TestPs_EncodeAndValidate, %s: %s", d.alg, err)
+			continue
+		}
+		if err := d.signer.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestPs_EncodeAndValidate, %s: signer: %s", d.alg, err)
+		}
+		if err := d.verifier.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestPs_EncodeAndValidate, %s: verifier: %s", d.alg, err)
+		}
+	}
+}
+
+func TestPs_SaltLength(t *testing.T) {
+	key := rsaTestKey(t)
+	signer := PsSha256(key)
+	signer.SetSaltLength(20)
+	encoded, err := signer.Encode(NewClaims())
+	if err != nil {
+		t.Fatalf("jwt.TestPs_SaltLength: %s", err)
+	}
+	strict := PsSha256Verifier(&key.PublicKey)
+	if err := strict.Validate(encoded); err == nil {
+		t.Errorf("jwt.TestPs_SaltLength: non-standard salt length is accepted")
+	}
+	lenient := PsSha256Verifier(&key.PublicKey)
+	lenient.SetSaltLength(rsa.PSSSaltLengthAuto)
+	if err := lenient.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestPs_SaltLength: %s", err)
+	}
+}
+
+func TestEs_EncodeAndValidate(t *testing.T) {
+	var data = []struct {
+		curve elliptic.Curve
+		sign  func(*ecdsa.PrivateKey) JWT
+		verif func(*ecdsa.PublicKey) JWT
+		alg   string
+		size  int
+	}{
+		{curve: elliptic.P256(), sign: EsSha256, verif: EsSha256Verifier, alg: "ES256", size: 64},
+		{curve: elliptic.P384(), sign: EsSha384, verif: EsSha384Verifier, alg: "ES384", size: 96},
+		{curve: elliptic.P521(), sign: EsSha512, verif: EsSha512Verifier, alg: "ES512", size: 132},
+	}
+	for _, d := range data {
+		key, err := ecdsa.GenerateKey(d.curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("jwt.TestEs_EncodeAndValidate, %s: %s", d.alg, err)
+		}
+		signer := d.sign(key)
+		encoded, err := signer.Encode(NewClaims())
+		if err != nil {
+			t.Errorf("jwt.TestEs_EncodeAndValidate, %s: %s", d.alg, err)
+			continue
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(strings.Split(encoded, ".")[2])
+		if len(signature) != d.size {
+			t.Errorf("jwt.TestEs_EncodeAndValidate, %s: invalid signature len: %d != %d", d.alg, len(signature), d.size)
+		}
+		verifier := d.verif(&key.PublicKey)
+		if err := verifier.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestEs_EncodeAndValidate, %s: %s", d.alg, err)
+		}
+	}
+}
+
+func TestEs_ValidateDERSignature(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer := EsSha256(key)
+	encoded, _ := signer.Encode(NewClaims())
+	segments := strings.Split(encoded, ".")
+	hashed := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	der, err := ecdsa.SignASN1(rand.Reader, key, hashed[:])
+	if err != nil {
+		t.Fatalf("jwt.TestEs_ValidateDERSignature: %s", err)
+	}
+	tampered := segments[0] + "." + segments[1] + "." + base64.RawURLEncoding.EncodeToString(der)
+	if err := signer.Validate(tampered); err == nil {
+		t.Errorf("jwt.TestEs_ValidateDERSignature: DER signature is accepted")
+	}
+}
+
+func TestEs_InvalidCurve(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	signer := EsSha256(key)
+	if _, err := signer.Encode(NewClaims()); err == nil {
+		t.Errorf("jwt.TestEs_InvalidCurve: P-384 key is accepted by ES256")
+	}
+}
+
+func TestEd25519_EncodeAndValidate(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("jwt.TestEd25519_EncodeAndValidate: %s", err)
+	}
+	signer := Ed25519(privateKey)
+	if header := signer.NewHeader(); header.Alg != "EdDSA" {
+		t.Errorf("jwt.TestEd25519_EncodeAndValidate: invalid Alg: %s", header.Alg)
+	}
+	claims := NewClaims()
+	first, err := signer.Encode(claims)
+	if err != nil {
+		t.Fatalf("jwt.TestEd25519_EncodeAndValidate: %s", err)
+	}
+	second, _ := signer.Encode(claims)
+	if first != second {
+		t.Errorf("jwt.TestEd25519_EncodeAndValidate: signature is not deterministic")
+	}
+	verifier := Ed25519Verifier(publicKey)
+	if err := verifier.Validate(first); err != nil {
+		t.Errorf("jwt.TestEd25519_EncodeAndValidate: %s", err)
+	}
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	other := Ed25519Verifier(otherKey)
+	if err := other.Validate(first); err == nil {
+		t.Errorf("jwt.TestEd25519_EncodeAndValidate: token is valid for another key")
+	}
+}
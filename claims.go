@@ -15,7 +15,12 @@
 
 package jwt
 
-import "time"
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
 
 // Claims represents a list of claims of a JWT token.
 //
@@ -102,11 +107,17 @@ type Claims struct {
 
 // NewClaims returns a new map representing the claims with "iat" claim value.
 func NewClaims() *Claims {
+	return NewClaimsWithClock(SystemClock)
+}
+
+// NewClaimsWithClock returns a new map representing the claims with "iat" claim value
+// taken from the given clock.
+func NewClaimsWithClock(clock Clock) *Claims {
 	newClaims := make(map[string]interface{})
 	claims := &Claims{
 		claims: newClaims,
 	}
-	claims.SetTime("iat", time.Now())
+	claims.SetTime("iat", clock.Now())
 	return claims
 }
 
@@ -154,7 +165,7 @@ func (c *Claims) GetFloat64(key string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(float64)
+	val, ok := toFloat64(raw)
 	if !ok {
 		return 0, ErrClaimNotFloat64
 	}
@@ -167,11 +178,11 @@ func (c *Claims) GetFloat32(key string) (float32, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(float32)
-	if !ok {
+	val, ok := toFloat64(raw)
+	if !ok || math.Abs(val) > math.MaxFloat32 {
 		return 0, ErrClaimNotFloat32
 	}
-	return val, nil
+	return float32(val), nil
 }
 
 // GetInt8 attempts to return a claim as int8.
@@ -180,11 +191,11 @@ func (c *Claims) GetInt8(key string) (int8, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(int8)
-	if !ok {
+	val, ok := toInt64(raw)
+	if !ok || val < math.MinInt8 || val > math.MaxInt8 {
 		return 0, ErrClaimNotInt8
 	}
-	return val, nil
+	return int8(val), nil
 }
 
 // GetUint8 attempts to return a claim as uint8.
@@ -193,11 +204,11 @@ func (c *Claims) GetUint8(key string) (uint8, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(uint8)
-	if !ok {
+	val, ok := toUint64(raw)
+	if !ok || val > math.MaxUint8 {
 		return 0, ErrClaimNotUint8
 	}
-	return val, nil
+	return uint8(val), nil
 }
 
 // GetInt16 attempts to return a claim as int16.
@@ -206,11 +217,11 @@ func (c *Claims) GetInt16(key string) (int16, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(int16)
-	if !ok {
+	val, ok := toInt64(raw)
+	if !ok || val < math.MinInt16 || val > math.MaxInt16 {
 		return 0, ErrClaimNotInt16
 	}
-	return val, nil
+	return int16(val), nil
 }
 
 // GetUint16 attempts to return a claim as uint16.
@@ -219,11 +230,11 @@ func (c *Claims) GetUint16(key string) (uint16, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(uint16)
-	if !ok {
+	val, ok := toUint64(raw)
+	if !ok || val > math.MaxUint16 {
 		return 0, ErrClaimNotUint16
 	}
-	return val, nil
+	return uint16(val), nil
 }
 
 // GetInt attempts to return a claim as int.
@@ -232,11 +243,11 @@ func (c *Claims) GetInt(key string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(int)
-	if !ok {
+	val, ok := toInt64(raw)
+	if !ok || val < math.MinInt || val > math.MaxInt {
 		return 0, ErrClaimNotInt
 	}
-	return val, nil
+	return int(val), nil
 }
 
 // GetUint attempts to return a claim as uint.
@@ -245,11 +256,11 @@ func (c *Claims) GetUint(key string) (uint, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(uint)
-	if !ok {
+	val, ok := toUint64(raw)
+	if !ok || val > math.MaxUint {
 		return 0, ErrClaimNotUint
 	}
-	return val, nil
+	return uint(val), nil
 }
 
 // GetInt32 attempts to return a claim as int32.
@@ -258,11 +269,11 @@ func (c *Claims) GetInt32(key string) (int32, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(int32)
-	if !ok {
+	val, ok := toInt64(raw)
+	if !ok || val < math.MinInt32 || val > math.MaxInt32 {
 		return 0, ErrClaimNotInt32
 	}
-	return val, nil
+	return int32(val), nil
 }
 
 // GetUint32 attempts to return a claim as uint32.
@@ -271,11 +282,11 @@ func (c *Claims) GetUint32(key string) (uint32, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(uint32)
-	if !ok {
+	val, ok := toUint64(raw)
+	if !ok || val > math.MaxUint32 {
 		return 0, ErrClaimNotUint32
 	}
-	return val, nil
+	return uint32(val), nil
 }
 
 // GetInt64 attempts to return a claim as int64.
@@ -284,7 +295,7 @@ func (c *Claims) GetInt64(key string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(int64)
+	val, ok := toInt64(raw)
 	if !ok {
 		return 0, ErrClaimNotInt64
 	}
@@ -297,7 +308,7 @@ func (c *Claims) GetUint64(key string) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	val, ok := raw.(uint64)
+	val, ok := toUint64(raw)
 	if !ok {
 		return 0, ErrClaimNotUint64
 	}
@@ -318,7 +329,153 @@ func (c *Claims) GetBool(key string) (bool, error) {
 }
 
 // GetTime attempts to return a claim as a time.
+//
+// The claim is a NumericDate, i.e. the number of seconds since the epoch,
+// which may have a fractional part.
 func (c *Claims) GetTime(key string) (time.Time, error) {
-	val, err := c.GetInt64(key)
-	return time.Unix(val, 0), err
+	raw, err := c.Get(key)
+	if err != nil {
+		return time.Unix(0, 0), err
+	}
+	if val, ok := toInt64(raw); ok {
+		return time.Unix(val, 0), nil
+	}
+	if val, ok := toFloat64(raw); ok && val >= math.MinInt64 && val < math.MaxInt64 {
+		sec, frac := math.Modf(val)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return time.Unix(0, 0), ErrClaimNotNumericDate
+}
+
+// GetAudience attempts to return a claim as an audience, i.e. a string or an array of strings.
+func (c *Claims) GetAudience(key string) (Audience, error) {
+	raw, err := c.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	switch val := raw.(type) {
+	case string:
+		return Audience{val}, nil
+	case []string:
+		return val, nil
+	case Audience:
+		return val, nil
+	case []interface{}:
+		aud := make(Audience, 0, len(val))
+		for _, v := range val {
+			str, ok := v.(string)
+			if !ok {
+				return nil, ErrClaimNotAudience
+			}
+			aud = append(aud, str)
+		}
+		return aud, nil
+	}
+	return nil, ErrClaimNotAudience
+}
+
+// toInt64 converts a Go or a JSON number to int64.
+// It fails if the number has a fractional part or doesn't fit in int64.
+func toInt64(raw interface{}) (int64, bool) {
+	switch val := raw.(type) {
+	case int:
+		return int64(val), true
+	case int8:
+		return int64(val), true
+	case int16:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := toUint64(val)
+		if u > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(u), true
+	case float32:
+		return floatToInt64(float64(val))
+	case float64:
+		return floatToInt64(val)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := val.Float64(); err == nil {
+			return floatToInt64(f)
+		}
+	}
+	return 0, false
+}
+
+// toUint64 converts a Go or a JSON number to uint64.
+// It fails if the number is negative, has a fractional part or doesn't fit in uint64.
+func toUint64(raw interface{}) (uint64, bool) {
+	switch val := raw.(type) {
+	case uint:
+		return uint64(val), true
+	case uint8:
+		return uint64(val), true
+	case uint16:
+		return uint64(val), true
+	case uint32:
+		return uint64(val), true
+	case uint64:
+		return val, true
+	case int, int8, int16, int32, int64:
+		i, _ := toInt64(val)
+		if i < 0 {
+			return 0, false
+		}
+		return uint64(i), true
+	case float32:
+		return floatToUint64(float64(val))
+	case float64:
+		return floatToUint64(val)
+	case json.Number:
+		if u, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+			return u, true
+		}
+		if f, err := val.Float64(); err == nil {
+			return floatToUint64(f)
+		}
+	}
+	return 0, false
+}
+
+// toFloat64 converts a Go or a JSON number to float64.
+func toFloat64(raw interface{}) (float64, bool) {
+	switch val := raw.(type) {
+	case float32:
+		return float64(val), true
+	case float64:
+		return val, true
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	}
+	if i, ok := toInt64(raw); ok {
+		return float64(i), true
+	}
+	if u, ok := toUint64(raw); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
+
+// floatToInt64 converts an integral float to int64.
+func floatToInt64(val float64) (int64, bool) {
+	if val != math.Trunc(val) || val < math.MinInt64 || val >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(val), true
+}
+
+// floatToUint64 converts a non-negative integral float to uint64.
+func floatToUint64(val float64) (uint64, bool) {
+	if val != math.Trunc(val) || val < 0 || val >= math.MaxUint64 {
+		return 0, false
+	}
+	return uint64(val), true
 }
@@ -16,6 +16,8 @@
 package jwt
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -125,9 +127,13 @@ func TestClaims_GetTimeErr(t *testing.T) {
 	if err == nil {
 		t.Errorf("jwt.TestClaims_GetTimeErr: func does not return an error")
 	}
-	if err.Error() != ErrClaimNotInt64.Error() {
+	if err != ErrClaimNotNumericDate {
 		t.Errorf("jwt.TestClaims_GetTimeErr: func returns an invalid error")
 	}
+	claims.Set("key2", 1e300)
+	if _, err := claims.GetTime("key2"); err != ErrClaimNotNumericDate {
+		t.Errorf("jwt.TestClaims_GetTimeErr: %v != %v", err, ErrClaimNotNumericDate)
+	}
 }
 
 func TestClaims_GetInt8(t *testing.T) {
@@ -174,3 +180,84 @@ func TestClaims_GetBoolErr(t *testing.T) {
 		t.Errorf("jwt.TestClaims_GetBoolErr: %s", err)
 	}
 }
+
+var GetNumeric_TestClaimsData = []struct {
+	val   interface{}
+	get   func(c *Claims) error
+	valid bool
+}{
+	{val: json.Number("127"), get: func(c *Claims) error { _, err := c.GetInt8("key"); return err }, valid: true},
+	{val: json.Number("128"), get: func(c *Claims) error { _, err := c.GetInt8("key"); return err }, valid: false},
+	{val: json.Number("-1"), get: func(c *Claims) error { _, err := c.GetUint8("key"); return err }, valid: false},
+	{val: json.Number("65535"), get: func(c *Claims) error { _, err := c.GetUint16("key"); return err }, valid: true},
+	{val: json.Number("1.5"), get: func(c *Claims) error { _, err := c.GetInt32("key"); return err }, valid: false},
+	{val: json.Number("1e3"), get: func(c *Claims) error { _, err := c.GetInt32("key"); return err }, valid: true},
+	{val: json.Number("18446744073709551615"), get: func(c *Claims) error { _, err := c.GetUint64("key"); return err }, valid: true},
+	{val: json.Number("18446744073709551615"), get: func(c *Claims) error { _, err := c.GetInt64("key"); return err }, valid: false},
+	{val: float64(42), get: func(c *Claims) error { _, err := c.GetInt("key"); return err }, valid: true},
+	{val: float64(4.2), get: func(c *Claims) error { _, err := c.GetUint("key"); return err }, valid: false},
+	{val: int64(300), get: func(c *Claims) error { _, err := c.GetUint8("key"); return err }, valid: false},
+	{val: int(7), get: func(c *Claims) error { _, err := c.GetFloat64("key"); return err }, valid: true},
+	{val: json.Number("3.25"), get: func(c *Claims) error { _, err := c.GetFloat32("key"); return err }, valid: true},
+	{val: "42", get: func(c *Claims) error { _, err := c.GetInt64("key"); return err }, valid: false},
+}
+
+func TestClaims_GetNumeric(t *testing.T) {
+	for i, data := range GetNumeric_TestClaimsData {
+		claims := NewClaims()
+		claims.Set("key", data.val)
+		err := data.get(claims)
+		if data.valid && err != nil {
+			t.Errorf("jwt.TestClaims_GetNumeric[%d]: %s", i, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("jwt.TestClaims_GetNumeric[%d]: func does not return an error", i)
+		}
+	}
+}
+
+func TestClaims_GetTimeFraction(t *testing.T) {
+	claims := NewClaims()
+	claims.Set("exp", json.Number("1500000000.5"))
+	exp, err := claims.GetTime("exp")
+	if err != nil {
+		t.Fatalf("jwt.TestClaims_GetTimeFraction: %s", err)
+	}
+	if exp.Unix() != 1500000000 || exp.Nanosecond() != 500000000 {
+		t.Errorf("jwt.TestClaims_GetTimeFraction: invalid time: %s", exp)
+	}
+}
+
+func TestClaims_DecodedNumbers(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := NewClaims()
+	claims.Set("big", uint64(9007199254740993))
+	claims.SetTime("exp", time.Now().Add(time.Hour))
+	encoded, _ := hs256.Encode(claims)
+	decoded, err := hs256.DecodeAndValidate(encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestClaims_DecodedNumbers: %s", err)
+	}
+	if iat, _ := decoded.Get("iat"); reflect.TypeOf(iat) != reflect.TypeOf(float64(0)) {
+		t.Errorf("jwt.TestClaims_DecodedNumbers: %T is not float64", iat)
+	}
+	if _, err := decoded.GetTime("iat"); err != nil {
+		t.Errorf("jwt.TestClaims_DecodedNumbers: %s", err)
+	}
+
+	hs256.SetUseNumber(true)
+	decoded, err = hs256.DecodeAndValidate(encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestClaims_DecodedNumbers: %s", err)
+	}
+	if iat, _ := decoded.Get("iat"); reflect.TypeOf(iat) != reflect.TypeOf(json.Number("")) {
+		t.Errorf("jwt.TestClaims_DecodedNumbers: %T is not json.Number", iat)
+	}
+	big, err := decoded.GetUint64("big")
+	if err != nil {
+		t.Errorf("jwt.TestClaims_DecodedNumbers: %s", err)
+	}
+	if big != 9007199254740993 {
+		t.Errorf("jwt.TestClaims_DecodedNumbers: %d != %d", big, uint64(9007199254740993))
+	}
+}
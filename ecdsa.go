@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+)
+
+// SigningMethodECDSA implements the ECDSA signing methods.
+// It signs with a *ecdsa.PrivateKey and verifies with a *ecdsa.PublicKey.
+//
+// The signature is the concatenation of the fixed-width big-endian R and S
+// values as required by RFC 7518 §3.4 rather than the ASN.1 DER encoding.
+type SigningMethodECDSA struct {
+	Name      string
+	Hash      crypto.Hash
+	CurveBits int
+}
+
+var (
+	SigningMethodES256 = &SigningMethodECDSA{Name: "ES256", Hash: crypto.SHA256, CurveBits: 256}
+	SigningMethodES384 = &SigningMethodECDSA{Name: "ES384", Hash: crypto.SHA384, CurveBits: 384}
+	SigningMethodES512 = &SigningMethodECDSA{Name: "ES512", Hash: crypto.SHA512, CurveBits: 521}
+)
+
+func init() {
+	RegisterSigningMethod(SigningMethodES256)
+	RegisterSigningMethod(SigningMethodES384)
+	RegisterSigningMethod(SigningMethodES512)
+}
+
+// Alg returns the "alg" header value of the method.
+func (m *SigningMethodECDSA) Alg() string {
+	return m.Name
+}
+
+// keySize returns the size in bytes of each of the R and S values.
+func (m *SigningMethodECDSA) keySize() int {
+	return (m.CurveBits + 7) / 8
+}
+
+// Sign signs the data using ECDSA with the given private key.
+func (m *SigningMethodECDSA) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok || privateKey == nil {
+		return nil, ErrKeyNotECDSAPrivate
+	}
+	if privateKey.Curve.Params().BitSize != m.CurveBits {
+		return nil, ErrKeyInvalidCurve
+	}
+	hashed, err := digest(m.Hash, data)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hashed)
+	if err != nil {
+		return nil, err
+	}
+	keySize := m.keySize()
+	signature := make([]byte, 2*keySize)
+	r.FillBytes(signature[:keySize])
+	s.FillBytes(signature[keySize:])
+	return signature, nil
+}
+
+// Verify verifies a R||S ECDSA signature of the data using the given public key.
+func (m *SigningMethodECDSA) Verify(data, signature []byte, key interface{}) error {
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok || publicKey == nil {
+		return ErrKeyNotECDSAPublic
+	}
+	if publicKey.Curve.Params().BitSize != m.CurveBits {
+		return ErrKeyInvalidCurve
+	}
+	keySize := m.keySize()
+	if len(signature) != 2*keySize {
+		return ErrTokenInvalidSignatureLength
+	}
+	hashed, err := digest(m.Hash, data)
+	if err != nil {
+		return err
+	}
+	r := new(big.Int).SetBytes(signature[:keySize])
+	s := new(big.Int).SetBytes(signature[keySize:])
+	if !ecdsa.Verify(publicKey, hashed, r, s) {
+		return ErrTokenInvalidSignature
+	}
+	return nil
+}
+
+// ecdsaPublicKey returns the public part of the given private key or nil if the key is nil.
+func ecdsaPublicKey(key *ecdsa.PrivateKey) interface{} {
+	if key == nil {
+		return nil
+	}
+	return &key.PublicKey
+}
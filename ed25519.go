@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import "crypto/ed25519"
+
+// SigningMethodEd25519 implements the EdDSA signing method using Ed25519 (RFC 8037).
+// It signs with an ed25519.PrivateKey and verifies with an ed25519.PublicKey.
+type SigningMethodEd25519 struct{}
+
+var SigningMethodEdDSA = &SigningMethodEd25519{}
+
+func init() {
+	RegisterSigningMethod(SigningMethodEdDSA)
+}
+
+// Alg returns the "alg" header value of the method.
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Sign signs the data using Ed25519 with the given private key.
+//
+// Ed25519 hashes the message internally, so the data is signed as is (RFC 8037 §3.1).
+func (m *SigningMethodEd25519) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return nil, ErrKeyNotEd25519Private
+	}
+	return ed25519.Sign(privateKey, data), nil
+}
+
+// Verify verifies an Ed25519 signature of the data using the given public key.
+func (m *SigningMethodEd25519) Verify(data, signature []byte, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return ErrKeyNotEd25519Public
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return ErrTokenInvalidSignatureLength
+	}
+	if !ed25519.Verify(publicKey, data, signature) {
+		return ErrTokenInvalidSignature
+	}
+	return nil
+}
+
+// ed25519PublicKey returns the public part of the given private key or nil if the key is invalid.
+func ed25519PublicKey(key ed25519.PrivateKey) interface{} {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil
+	}
+	return key.Public()
+}
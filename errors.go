@@ -19,30 +19,88 @@ import "errors"
 
 var (
 	// Claims errors.
-	ErrClaimDoesNotExist = errors.New("claim does not exist")
-	ErrClaimNotAString   = errors.New("claim is not a string")
-	ErrClaimNotInt8      = errors.New("claim is not an int8")
-	ErrClaimNotUint8     = errors.New("claim is not an uint8")
-	ErrClaimNotInt16     = errors.New("claim is not an int16")
-	ErrClaimNotUint16    = errors.New("claim is not an uint16")
-	ErrClaimNotInt32     = errors.New("claim is not an int32")
-	ErrClaimNotUint32    = errors.New("claim is not an uint32")
-	ErrClaimNotInt       = errors.New("claim is not an int")
-	ErrClaimNotUint      = errors.New("claim is not an uint")
-	ErrClaimNotInt64     = errors.New("claim is not an int64")
-	ErrClaimNotUint64    = errors.New("claim is not an uint64")
-	ErrClaimNotFloat32   = errors.New("claim is not float32")
-	ErrClaimNotFloat64   = errors.New("claim is not float64")
-	ErrClaimNotBool      = errors.New("claim is not bool")
+	ErrClaimDoesNotExist   = errors.New("claim does not exist")
+	ErrClaimNotAString     = errors.New("claim is not a string")
+	ErrClaimNotInt8        = errors.New("claim is not an int8")
+	ErrClaimNotUint8       = errors.New("claim is not an uint8")
+	ErrClaimNotInt16       = errors.New("claim is not an int16")
+	ErrClaimNotUint16      = errors.New("claim is not an uint16")
+	ErrClaimNotInt32       = errors.New("claim is not an int32")
+	ErrClaimNotUint32      = errors.New("claim is not an uint32")
+	ErrClaimNotInt         = errors.New("claim is not an int")
+	ErrClaimNotUint        = errors.New("claim is not an uint")
+	ErrClaimNotInt64       = errors.New("claim is not an int64")
+	ErrClaimNotUint64      = errors.New("claim is not an uint64")
+	ErrClaimNotFloat32     = errors.New("claim is not float32")
+	ErrClaimNotFloat64     = errors.New("claim is not float64")
+	ErrClaimNotBool        = errors.New("claim is not bool")
+	ErrClaimNotNumericDate = errors.New("claim is not a numeric date")
+	ErrClaimNotAudience    = errors.New("claim is not a string or an array of strings")
 
 	// Token's errors.
-	ErrTokenIsMalformed               = errors.New("malformed token")
-	ErrTokenHasExpired                = errors.New("token has expired")
-	ErrTokenInvalidSignature          = errors.New("invalid signature")
-	ErrTokenUnableToSign              = errors.New("unable to sign token")
-	ErrTokenNotValid                  = errors.New("token isn't valid yet")
-	ErrTokenUnableToMarshallHeader    = errors.New("unable to marshal header")
-	ErrTokenUnableToMarshallPayload   = errors.New("unable to marshal payload")
-	ErrTokenUnableToDecodeB64Payload  = errors.New("unable to decode base64 payload")
-	ErrTokenUnableToUnmarshallPayload = errors.New("unable to unmarshal payload json")
+	ErrTokenIsMalformed                = errors.New("malformed token")
+	ErrTokenHasExpired                 = errors.New("token has expired")
+	ErrTokenInvalidSignature           = errors.New("invalid signature")
+	ErrTokenUnableToSign               = errors.New("unable to sign token")
+	ErrTokenUsedBeforeIssued           = errors.New("token used before issued")
+	ErrTokenMissingIssuedAt            = errors.New("token has no issued at time")
+	ErrTokenTooOld                     = errors.New("token is too old")
+	ErrTokenNotValid                   = errors.New("token isn't valid yet")
+	ErrTokenUnableToMarshallHeader     = errors.New("unable to marshal header")
+	ErrTokenUnableToMarshallPayload    = errors.New("unable to marshal payload")
+	ErrTokenUnableToDecodeB64Payload   = errors.New("unable to decode base64 payload")
+	ErrTokenUnableToUnmarshallPayload  = errors.New("unable to unmarshal payload json")
+	ErrTokenUnableToDecodeB64Signature = errors.New("unable to decode base64 signature")
+	ErrTokenInvalidSignatureLength     = errors.New("invalid signature length")
+	ErrTokenUnableToDecodeB64Header    = errors.New("unable to decode base64 header")
+	ErrTokenUnableToUnmarshallHeader   = errors.New("unable to unmarshal header json")
+	ErrTokenInvalidAudience            = errors.New("token has invalid audience")
+	ErrTokenInvalidIssuer              = errors.New("token has invalid issuer")
+	ErrTokenMissingSubject             = errors.New("token has no subject")
+	ErrTokenInvalidSubject             = errors.New("token has invalid subject")
+	ErrTokenMissingID                  = errors.New("token has no id")
+	ErrTokenInvalidID                  = errors.New("token has invalid id")
+	ErrTokenInvalidCritical            = errors.New("token has invalid crit header")
+	ErrTokenUnsupportedCritical        = errors.New("token has unsupported critical header")
+	ErrTokenKeyLookup                  = errors.New("unable to find verification key")
+	ErrTokenAlgorithmNotAllowed        = errors.New("algorithm is not allowed")
+
+	// Signing method errors.
+	ErrSigningMethodNotRegistered = errors.New("signing method is not registered")
+	ErrHashUnavailable            = errors.New("the requested hash function is unavailable")
+
+	// Key errors.
+	ErrKeyNotHMACSecret     = errors.New("key is not an HMAC secret")
+	ErrKeyNotRSAPrivate     = errors.New("key is not an RSA private key")
+	ErrKeyNotRSAPublic      = errors.New("key is not an RSA public key")
+	ErrKeyNotECDSAPrivate   = errors.New("key is not an ECDSA private key")
+	ErrKeyNotECDSAPublic    = errors.New("key is not an ECDSA public key")
+	ErrKeyInvalidCurve      = errors.New("key curve doesn't match the algorithm")
+	ErrKeyNotEd25519Private = errors.New("key is not an Ed25519 private key")
+	ErrKeyNotEd25519Public  = errors.New("key is not an Ed25519 public key")
+
+	// Key ring errors.
+	ErrKeyRingInvalidKey   = errors.New("key ring key has no id, signing method or verification key")
+	ErrKeyRingDuplicateKey = errors.New("key ring already has a key with the id")
+	ErrKeyRingNoActiveKey  = errors.New("key ring has no active signing key")
+	ErrKeyRingUnknownKey   = errors.New("key ring has no key with the id")
+	ErrKeyRingKeyRetired   = errors.New("key ring key is retired")
+
+	// JWK errors.
+	ErrJWKMissingMember          = errors.New("jwk is missing a required member")
+	ErrJWKInvalidMember          = errors.New("jwk has an invalid member")
+	ErrJWKUnsupportedKeyType     = errors.New("jwk has an unsupported key type")
+	ErrJWKUnsupportedCurve       = errors.New("jwk has an unsupported curve")
+	ErrJWKUnsupportedOtherPrimes = errors.New("jwk has unsupported other primes")
+	ErrJWKUnsupportedPrivateKey  = errors.New("jwk has an unsupported private key without its primes")
+	ErrJWKInvalidKey             = errors.New("jwk has an invalid key")
+	ErrJWKInvalidKeyOps          = errors.New("jwk has invalid key operations")
+	ErrJWKAlgorithmMismatch      = errors.New("jwk algorithm doesn't match its key type")
+	ErrJWKUnsupportedKey         = errors.New("key can't be represented as a jwk")
+
+	// JWK Set errors.
+	ErrJWKSetKeyNotFound = errors.New("jwk set has no matching key")
+
+	// Remote JWK Set errors.
+	ErrJWKSFetch = errors.New("unable to fetch jwk set")
 )
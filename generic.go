@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+// EncodeClaims returns an encoded JWT token with the given claims as payload.
+// The claims may be of any type encoding/json marshals into an object,
+// e.g. a struct which embeds RegisteredClaims, a map or *Claims, otherwise
+// ErrTokenUnableToMarshallPayload is returned.
+func EncodeClaims[T any](token *JWT, claims T) (string, error) {
+	return token.encode(token.NewHeader(), claims)
+}
+
+// DecodeClaims returns the claims of the token decoded into a value of type T.
+// The token is validated as by JWT.DecodeAndValidate, so an error is returned
+// if the signature, exp or nbf claim is invalid.
+func DecodeClaims[T any](token *JWT, encoded string) (T, error) {
+	var claims T
+	if err := token.DecodeAndValidateCustomClaims(encoded, &claims); err != nil {
+		var zero T
+		return zero, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncodeClaims_Struct(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	encoded, err := EncodeClaims(&hs256, testUserClaims{
+		RegisteredClaims: RegisteredClaims{Subject: "user"},
+		Name:             "John Doe",
+	})
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Struct: %s", err)
+	}
+	decoded, err := DecodeClaims[testUserClaims](&hs256, encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Struct: %s", err)
+	}
+	if decoded.Subject != "user" || decoded.Name != "John Doe" {
+		t.Errorf("jwt.TestEncodeClaims_Struct: invalid claims: %+v", decoded)
+	}
+}
+
+func TestEncodeClaims_Map(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	encoded, err := EncodeClaims(&hs256, map[string]interface{}{"sub": "user", "count": 3})
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Map: %s", err)
+	}
+	decoded, err := DecodeClaims[map[string]interface{}](&hs256, encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Map: %s", err)
+	}
+	if decoded["sub"] != "user" || decoded["count"] != float64(3) {
+		t.Errorf("jwt.TestEncodeClaims_Map: invalid claims: %v", decoded)
+	}
+}
+
+func TestDecodeClaims_Invalid(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	encoded, _ := EncodeClaims(&hs256, RegisteredClaims{
+		NotBefore: NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if _, err := DecodeClaims[RegisteredClaims](&hs256, encoded); err == nil {
+		t.Errorf("jwt.TestDecodeClaims_Invalid: token which isn't valid yet is accepted")
+	}
+	other := HmacSha256("another-secret-key")
+	encoded, _ = EncodeClaims(&other, RegisteredClaims{Subject: "user"})
+	if _, err := DecodeClaims[RegisteredClaims](&hs256, encoded); err == nil {
+		t.Errorf("jwt.TestDecodeClaims_Invalid: token with invalid signature is accepted")
+	}
+}
+
+func TestEncodeClaims_NotObject(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	if _, err := EncodeClaims(&hs256, 42); err != ErrTokenUnableToMarshallPayload {
+		t.Errorf("jwt.TestEncodeClaims_NotObject: %v != %v", err, ErrTokenUnableToMarshallPayload)
+	}
+	if _, err := EncodeClaims(&hs256, []string{"sub"}); err != ErrTokenUnableToMarshallPayload {
+		t.Errorf("jwt.TestEncodeClaims_NotObject: %v != %v", err, ErrTokenUnableToMarshallPayload)
+	}
+	var claims map[string]interface{}
+	if _, err := EncodeClaims(&hs256, claims); err != ErrTokenUnableToMarshallPayload {
+		t.Errorf("jwt.TestEncodeClaims_NotObject: %v != %v", err, ErrTokenUnableToMarshallPayload)
+	}
+}
+
+func TestEncodeClaims_Claims(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := NewClaims()
+	claims.Set("sub", "user")
+	encoded, err := EncodeClaims(&hs256, claims)
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Claims: %s", err)
+	}
+	decoded, err := DecodeClaims[*Claims](&hs256, encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Claims: %s", err)
+	}
+	if sub, _ := decoded.GetString("sub"); sub != "user" {
+		t.Errorf("jwt.TestEncodeClaims_Claims: invalid sub: %s", sub)
+	}
+	value, err := DecodeClaims[Claims](&hs256, encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestEncodeClaims_Claims: %s", err)
+	}
+	if sub, _ := value.GetString("sub"); sub != "user" {
+		t.Errorf("jwt.TestEncodeClaims_Claims: invalid sub: %s", sub)
+	}
+}
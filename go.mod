@@ -1 +1,3 @@
 module github.com/YuriyLisovskiy/jwt-go
+
+go 1.20
@@ -15,11 +15,19 @@
 
 package jwt
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 // Represents JWT Header.
 // Contains important information for encrypting/decrypting.
+//
+// Registered Header Parameter Names (source: https://tools.ietf.org/html/rfc7515#section-4.1)
+// which are empty are omitted when the header is encoded.
 type Header struct {
 	// Token type.
-	Typ string `json:"typ"`
+	Typ string `json:"typ,omitempty"`
 
 	// Message authentication code algorithm - the issuer can freely set an algorithm
 	// to verify the signature on the token. However, some asymmetrical algorithms
@@ -27,5 +35,121 @@ type Header struct {
 	Alg string `json:"alg"`
 
 	// Content type - this always is JWT.
-	Cty string `json:"cty"`
+	Cty string `json:"cty,omitempty"`
+
+	// Key ID - a hint indicating which key was used to sign the token.
+	Kid string `json:"kid,omitempty"`
+
+	// JWK Set URL - the URL of the set of keys containing the signing key.
+	Jku string `json:"jku,omitempty"`
+
+	// JSON Web Key - the public key corresponding to the signing key.
+	Jwk json.RawMessage `json:"jwk,omitempty"`
+
+	// X.509 URL - the URL of the certificate or certificate chain of the signing key.
+	X5u string `json:"x5u,omitempty"`
+
+	// X.509 Certificate Chain - base64 DER encoded certificates of the signing key.
+	X5c []string `json:"x5c,omitempty"`
+
+	// X.509 Certificate SHA-1 Thumbprint - base64url encoded.
+	X5t string `json:"x5t,omitempty"`
+
+	// X.509 Certificate SHA-256 Thumbprint - base64url encoded.
+	X5tS256 string `json:"x5t#S256,omitempty"`
+
+	// Critical - the extensions which must be understood and processed.
+	Crit []string `json:"crit,omitempty"`
+
+	// Extra holds the private and unregistered header parameters.
+	// Registered parameters can't be overridden by it.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// registeredHeaderParameters is the set of the header parameter names
+// registered by RFC 7515 §4.1.
+var registeredHeaderParameters = map[string]bool{
+	"alg":      true,
+	"jku":      true,
+	"jwk":      true,
+	"kid":      true,
+	"x5u":      true,
+	"x5c":      true,
+	"x5t":      true,
+	"x5t#S256": true,
+	"typ":      true,
+	"cty":      true,
+	"crit":     true,
+}
+
+// headerFields is used to (un)marshal the registered header parameters
+// without recursing into the methods of Header.
+type headerFields Header
+
+// Get returns the value of the private header parameter with the given name
+// and reports if the header has it.
+func (h *Header) Get(name string) (interface{}, bool) {
+	value, ok := h.Extra[name]
+	return value, ok
+}
+
+// Set sets the private header parameter with the given name.
+func (h *Header) Set(name string, value interface{}) {
+	if h.Extra == nil {
+		h.Extra = make(map[string]interface{})
+	}
+	h.Extra[name] = value
+}
+
+// MarshalJSON encodes the registered header parameters along with the extra ones.
+func (h Header) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(headerFields(h))
+	if err != nil || len(h.Extra) == 0 {
+		return data, err
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	for name, value := range h.Extra {
+		if registeredHeaderParameters[name] {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		params[name] = raw
+	}
+	return json.Marshal(params)
+}
+
+// UnmarshalJSON decodes the registered header parameters and keeps
+// the unknown ones in Extra.
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var fields headerFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	for name, raw := range params {
+		if registeredHeaderParameters[name] {
+			continue
+		}
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if fields.Extra == nil {
+			fields.Extra = make(map[string]interface{})
+		}
+		fields.Extra[name] = value
+	}
+	*h = Header(fields)
+	return nil
 }
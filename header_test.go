@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestHeader_MarshalJSON(t *testing.T) {
+	var data = []struct {
+		header   Header
+		expected string
+	}{
+		{
+			header:   Header{Typ: "JWT", Alg: "HS256"},
+			expected: `{"typ":"JWT","alg":"HS256"}`,
+		},
+		{
+			header:   Header{Alg: "RS256", Kid: "key-1", X5tS256: "thumbprint", Crit: []string{"exp"}},
+			expected: `{"alg":"RS256","kid":"key-1","x5t#S256":"thumbprint","crit":["exp"]}`,
+		},
+		{
+			header:   Header{Alg: "ES256", Extra: map[string]interface{}{"exp": 1500000000, "alg": "none"}},
+			expected: `{"alg":"ES256","exp":1500000000}`,
+		},
+	}
+	for _, d := range data {
+		actual, err := json.Marshal(d.header)
+		if err != nil {
+			t.Errorf("jwt.TestHeader_MarshalJSON: %s", err)
+			continue
+		}
+		if string(actual) != d.expected {
+			t.Errorf("jwt.TestHeader_MarshalJSON: %s != %s", actual, d.expected)
+		}
+	}
+}
+
+func TestHeader_UnmarshalJSON(t *testing.T) {
+	var header Header
+	data := `{"alg":"RS256","kid":"key-1","x5c":["MIIB"],"exp":1500000000,"tenant":"acme"}`
+	if err := json.Unmarshal([]byte(data), &header); err != nil {
+		t.Fatalf("jwt.TestHeader_UnmarshalJSON: %s", err)
+	}
+	if header.Alg != "RS256" || header.Kid != "key-1" || len(header.X5c) != 1 {
+		t.Errorf("jwt.TestHeader_UnmarshalJSON: invalid header: %+v", header)
+	}
+	if tenant, ok := header.Get("tenant"); !ok || tenant != "acme" {
+		t.Errorf("jwt.TestHeader_UnmarshalJSON: invalid tenant: %v", tenant)
+	}
+	if exp, ok := header.Get("exp"); !ok || exp != json.Number("1500000000") {
+		t.Errorf("jwt.TestHeader_UnmarshalJSON: invalid exp: %v", exp)
+	}
+	if _, ok := header.Get("kid"); ok {
+		t.Errorf("jwt.TestHeader_UnmarshalJSON: registered parameter is kept in Extra")
+	}
+}
+
+func TestJWT_EncodeWithHeader(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	header := hs256.NewHeader()
+	header.Kid = "key-1"
+	header.Alg = "none"
+	header.Set("tenant", "acme")
+	encoded, err := hs256.EncodeWithHeader(header, NewClaims())
+	if err != nil {
+		t.Fatalf("jwt.TestJWT_EncodeWithHeader: %s", err)
+	}
+	parsed, err := hs256.Parse(encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestJWT_EncodeWithHeader: %s", err)
+	}
+	if parsed.Header.Kid != "key-1" || parsed.Header.Alg != "HS256" {
+		t.Errorf("jwt.TestJWT_EncodeWithHeader: invalid header: %+v", parsed.Header)
+	}
+	if tenant, _ := parsed.Header.Get("tenant"); tenant != "acme" {
+		t.Errorf("jwt.TestJWT_EncodeWithHeader: invalid tenant: %v", tenant)
+	}
+	if header.Alg != "none" {
+		t.Errorf("jwt.TestJWT_EncodeWithHeader: given header is modified")
+	}
+}
+
+func TestJWT_ValidateCritical(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	hs256.SetCriticalHeaders("tenant")
+	var data = []struct {
+		name   string
+		crit   []string
+		params map[string]interface{}
+		err    error
+	}{
+		{name: "no crit", crit: nil, err: nil},
+		{name: "supported", crit: []string{"tenant"}, params: map[string]interface{}{"tenant": "acme"}, err: nil},
+		{name: "registered", crit: []string{"kid"}, err: ErrTokenInvalidCritical},
+		{name: "missing", crit: []string{"tenant"}, err: ErrTokenInvalidCritical},
+		{name: "unsupported", crit: []string{"b64"}, params: map[string]interface{}{"b64": false}, err: ErrTokenUnsupportedCritical},
+	}
+	for _, d := range data {
+		header := hs256.NewHeader()
+		header.Crit = d.crit
+		header.Extra = d.params
+		encoded, err := hs256.EncodeWithHeader(header, NewClaims())
+		if err != nil {
+			t.Errorf("jwt.TestJWT_ValidateCritical, %s: %s", d.name, err)
+			continue
+		}
+		err = hs256.Validate(encoded)
+		if d.err == nil && err != nil {
+			t.Errorf("jwt.TestJWT_ValidateCritical, %s: %s", d.name, err)
+		}
+		if d.err != nil && !errors.Is(err, d.err) {
+			t.Errorf("jwt.TestJWT_ValidateCritical, %s: %v is not %v", d.name, err, d.err)
+		}
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","crit":[]}`)) + ".e30"
+	signature, _ := hs256.Sign(unsigned)
+	encoded := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	if err := hs256.Validate(encoded); !errors.Is(err, ErrTokenInvalidCritical) {
+		t.Errorf("jwt.TestJWT_ValidateCritical, empty: %v is not %v", err, ErrTokenInvalidCritical)
+	}
+}
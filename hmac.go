@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+)
+
+// SigningMethodHMAC implements the HMAC signing methods.
+// The key is a []byte or a string for both signing and verification.
+type SigningMethodHMAC struct {
+	Name string
+	Hash crypto.Hash
+}
+
+var (
+	SigningMethodHS256 = &SigningMethodHMAC{Name: "HS256", Hash: crypto.SHA256}
+	SigningMethodHS384 = &SigningMethodHMAC{Name: "HS384", Hash: crypto.SHA384}
+	SigningMethodHS512 = &SigningMethodHMAC{Name: "HS512", Hash: crypto.SHA512}
+)
+
+func init() {
+	RegisterSigningMethod(SigningMethodHS256)
+	RegisterSigningMethod(SigningMethodHS384)
+	RegisterSigningMethod(SigningMethodHS512)
+}
+
+// Alg returns the "alg" header value of the method.
+func (m *SigningMethodHMAC) Alg() string {
+	return m.Name
+}
+
+// Sign returns the HMAC of the data.
+//
+// A new HMAC is created for each call, so the method can be shared by goroutines.
+func (m *SigningMethodHMAC) Sign(data []byte, key interface{}) ([]byte, error) {
+	secret, err := hmacKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Hash.Available() {
+		return nil, ErrHashUnavailable
+	}
+	mac := hmac.New(m.Hash.New, secret)
+	if _, err := mac.Write(data); err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil), nil
+}
+
+// Verify computes the HMAC of the data and compares it with the signature in constant time.
+func (m *SigningMethodHMAC) Verify(data, signature []byte, key interface{}) error {
+	expected, err := m.Sign(data, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(signature, expected) {
+		return ErrTokenInvalidSignature
+	}
+	return nil
+}
+
+// hmacKey returns the HMAC secret held by the given key.
+func hmacKey(key interface{}) ([]byte, error) {
+	switch secret := key.(type) {
+	case []byte:
+		return secret, nil
+	case string:
+		return []byte(secret), nil
+	default:
+		return nil, ErrKeyNotHMACSecret
+	}
+}
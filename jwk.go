@@ -0,0 +1,511 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK represents a JSON Web Key (source: https://tools.ietf.org/html/rfc7517).
+//
+// Key holds the key itself, which is one of:
+//   - []byte for "oct" keys;
+//   - *rsa.PublicKey or *rsa.PrivateKey for "RSA" keys;
+//   - *ecdsa.PublicKey or *ecdsa.PrivateKey for "EC" keys on P-256, P-384 or P-521;
+//   - ed25519.PublicKey or ed25519.PrivateKey for "OKP" keys on Ed25519.
+//
+// RSA private keys must have exactly two primes, since neither the "oth"
+// member nor the private keys without the prime factors are supported.
+type JWK struct {
+	// Key is the Go crypto key.
+	Key interface{}
+
+	// KeyID - the "kid" used to match the key, e.g. with the "kid" header of a token.
+	KeyID string
+
+	// Algorithm - the "alg" the key is intended to be used with.
+	Algorithm string
+
+	// Use - the intended "use" of a public key, "sig" or "enc".
+	Use string
+
+	// KeyOps - the operations the key is intended to be used for.
+	KeyOps []string
+}
+
+// jwkFields is the JSON representation of a JWK.
+type jwkFields struct {
+	Kty    string          `json:"kty"`
+	Use    string          `json:"use,omitempty"`
+	KeyOps []string        `json:"key_ops,omitempty"`
+	Alg    string          `json:"alg,omitempty"`
+	Kid    string          `json:"kid,omitempty"`
+	Crv    string          `json:"crv,omitempty"`
+	K      string          `json:"k,omitempty"`
+	X      string          `json:"x,omitempty"`
+	Y      string          `json:"y,omitempty"`
+	N      string          `json:"n,omitempty"`
+	E      string          `json:"e,omitempty"`
+	D      string          `json:"d,omitempty"`
+	P      string          `json:"p,omitempty"`
+	Q      string          `json:"q,omitempty"`
+	Dp     string          `json:"dp,omitempty"`
+	Dq     string          `json:"dq,omitempty"`
+	Qi     string          `json:"qi,omitempty"`
+	Oth    json.RawMessage `json:"oth,omitempty"`
+}
+
+// jwkKeyOps maps the "key_ops" values registered by RFC 7517 §4.3
+// to the "use" they are consistent with.
+var jwkKeyOps = map[string]string{
+	"sign":       "sig",
+	"verify":     "sig",
+	"encrypt":    "enc",
+	"decrypt":    "enc",
+	"wrapKey":    "enc",
+	"unwrapKey":  "enc",
+	"deriveKey":  "enc",
+	"deriveBits": "enc",
+}
+
+// jwkAlgorithms maps the signing algorithms to the "kty" and, for the
+// elliptic curve algorithms, the "crv" of the keys they are used with.
+var jwkAlgorithms = map[string][2]string{
+	"HS256": {"oct"},
+	"HS384": {"oct"},
+	"HS512": {"oct"},
+	"RS256": {"RSA"},
+	"RS384": {"RSA"},
+	"RS512": {"RSA"},
+	"PS256": {"RSA"},
+	"PS384": {"RSA"},
+	"PS512": {"RSA"},
+	"ES256": {"EC", "P-256"},
+	"ES384": {"EC", "P-384"},
+	"ES512": {"EC", "P-521"},
+	"EdDSA": {"OKP"},
+}
+
+// jwkCurves maps the "crv" values of the supported "EC" keys to their curves.
+var jwkCurves = map[string]func() (elliptic.Curve, ecdh.Curve){
+	"P-256": func() (elliptic.Curve, ecdh.Curve) { return elliptic.P256(), ecdh.P256() },
+	"P-384": func() (elliptic.Curve, ecdh.Curve) { return elliptic.P384(), ecdh.P384() },
+	"P-521": func() (elliptic.Curve, ecdh.Curve) { return elliptic.P521(), ecdh.P521() },
+}
+
+// NewJWK returns a JWK holding the given key, see JWK for the supported keys.
+// A string is accepted as an "oct" key as well.
+func NewJWK(key interface{}) (*JWK, error) {
+	if s, ok := key.(string); ok {
+		key = []byte(s)
+	}
+	if _, err := jwkKeyFields(key); err != nil {
+		return nil, err
+	}
+	return &JWK{Key: key}, nil
+}
+
+// ParseJWK parses and validates the JSON representation of a JWK.
+func ParseJWK(data []byte) (*JWK, error) {
+	jwk := &JWK{}
+	if err := json.Unmarshal(data, jwk); err != nil {
+		return nil, err
+	}
+	return jwk, nil
+}
+
+// KeyType returns the "kty" of the key or an empty string if it isn't supported.
+func (jwk *JWK) KeyType() string {
+	switch jwk.Key.(type) {
+	case []byte:
+		return "oct"
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return "RSA"
+	case *ecdsa.PublicKey, *ecdsa.PrivateKey:
+		return "EC"
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return "OKP"
+	}
+	return ""
+}
+
+// VerificationKey returns the key to verify signatures with, i.e. the public
+// key of an asymmetric key pair or the symmetric key itself.
+func (jwk *JWK) VerificationKey() interface{} {
+	switch k := jwk.Key.(type) {
+	case *rsa.PrivateKey:
+		return &k.PublicKey
+	case *ecdsa.PrivateKey:
+		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
+	}
+	return jwk.Key
+}
+
+// curve returns the "crv" of an elliptic curve key or an empty string.
+func (jwk *JWK) curve() string {
+	switch k := jwk.Key.(type) {
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().Name
+	case *ecdsa.PrivateKey:
+		return k.Curve.Params().Name
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return "Ed25519"
+	}
+	return ""
+}
+
+// MarshalJSON encodes the key along with its metadata.
+func (jwk JWK) MarshalJSON() ([]byte, error) {
+	fields, err := jwkKeyFields(jwk.Key)
+	if err != nil {
+		return nil, err
+	}
+	fields.Kid = jwk.KeyID
+	fields.Alg = jwk.Algorithm
+	fields.Use = jwk.Use
+	fields.KeyOps = jwk.KeyOps
+	if err := fields.validateMetadata(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes and validates the key along with its metadata.
+//
+// The members required by RFC 7518 §6 for the key type must be present and
+// well-formed. Unknown members are ignored as required by RFC 7517 §4.
+func (jwk *JWK) UnmarshalJSON(data []byte) error {
+	var fields jwkFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	if err := fields.validateMetadata(); err != nil {
+		return err
+	}
+	var key interface{}
+	var err error
+	switch fields.Kty {
+	case "oct":
+		key, err = fields.octKey()
+	case "RSA":
+		key, err = fields.rsaKey(members)
+	case "EC":
+		key, err = fields.ecKey(members)
+	case "OKP":
+		key, err = fields.okpKey(members)
+	case "":
+		return fmt.Errorf("%w: kty", ErrJWKMissingMember)
+	default:
+		return ErrJWKUnsupportedKeyType
+	}
+	if err != nil {
+		return err
+	}
+	*jwk = JWK{
+		Key:       key,
+		KeyID:     fields.Kid,
+		Algorithm: fields.Alg,
+		Use:       fields.Use,
+		KeyOps:    fields.KeyOps,
+	}
+	return nil
+}
+
+// validateMetadata verifies the "key_ops" are unique and the registered ones
+// are consistent with the "use", and the "alg" matches the key type.
+// Other "key_ops" values are allowed by RFC 7517 §4.3.
+func (fields *jwkFields) validateMetadata() error {
+	seen := make(map[string]bool, len(fields.KeyOps))
+	for _, op := range fields.KeyOps {
+		use, registered := jwkKeyOps[op]
+		if seen[op] || (registered && fields.Use != "" && use != fields.Use) {
+			return ErrJWKInvalidKeyOps
+		}
+		seen[op] = true
+	}
+	if keyType, ok := jwkAlgorithms[fields.Alg]; ok {
+		if keyType[0] != fields.Kty || (keyType[1] != "" && keyType[1] != fields.Crv) {
+			return ErrJWKAlgorithmMismatch
+		}
+	}
+	return nil
+}
+
+// member decodes the base64url encoded member with the given name, which
+// must be present and must have exactly size bytes if size isn't zero.
+func (fields *jwkFields) member(name, value string, size int) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%w: %s", ErrJWKMissingMember, name)
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || (size != 0 && len(decoded) != size) {
+		return nil, fmt.Errorf("%w: %s", ErrJWKInvalidMember, name)
+	}
+	return decoded, nil
+}
+
+// bigMember decodes the base64url encoded unsigned integer member with the given name.
+func (fields *jwkFields) bigMember(name, value string) (*big.Int, error) {
+	decoded, err := fields.member(name, value, 0)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+// octKey returns the symmetric key of an "oct" JWK.
+func (fields *jwkFields) octKey() ([]byte, error) {
+	return fields.member("k", fields.K, 0)
+}
+
+// rsaKey returns the public or private key of an "RSA" JWK.
+func (fields *jwkFields) rsaKey(members map[string]json.RawMessage) (interface{}, error) {
+	n, err := fields.bigMember("n", fields.N)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() == 0 {
+		return nil, fmt.Errorf("%w: n", ErrJWKInvalidMember)
+	}
+	e, err := fields.bigMember("e", fields.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("%w: e", ErrJWKInvalidMember)
+	}
+	publicKey := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if _, ok := members["d"]; !ok {
+		return publicKey, nil
+	}
+	if _, ok := members["oth"]; ok {
+		return nil, ErrJWKUnsupportedOtherPrimes
+	}
+	// The members of the prime factors are optional, but all or nothing.
+	primes := 0
+	for _, name := range []string{"p", "q", "dp", "dq", "qi"} {
+		if _, ok := members[name]; ok {
+			primes++
+		}
+	}
+	if primes == 0 {
+		return nil, ErrJWKUnsupportedPrivateKey
+	}
+	params := []struct{ name, value string }{
+		{"d", fields.D}, {"p", fields.P}, {"q", fields.Q},
+		{"dp", fields.Dp}, {"dq", fields.Dq}, {"qi", fields.Qi},
+	}
+	values := make([]*big.Int, len(params))
+	for i, param := range params {
+		if values[i], err = fields.bigMember(param.name, param.value); err != nil {
+			return nil, err
+		}
+	}
+	privateKey := &rsa.PrivateKey{
+		PublicKey: *publicKey,
+		D:         values[0],
+		Primes:    []*big.Int{values[1], values[2]},
+	}
+	if err := privateKey.Validate(); err != nil {
+		return nil, ErrJWKInvalidKey
+	}
+	dp, dq, qi := rsaCRTValues(privateKey)
+	if dp.Cmp(values[3]) != 0 || dq.Cmp(values[4]) != 0 || qi.Cmp(values[5]) != 0 {
+		return nil, ErrJWKInvalidKey
+	}
+	privateKey.Precompute()
+	return privateKey, nil
+}
+
+// rsaCRTValues returns the "dp", "dq" and "qi" values of a two-prime RSA private key.
+func rsaCRTValues(key *rsa.PrivateKey) (dp, dq, qi *big.Int) {
+	p, q := key.Primes[0], key.Primes[1]
+	one := big.NewInt(1)
+	dp = new(big.Int).Mod(key.D, new(big.Int).Sub(p, one))
+	dq = new(big.Int).Mod(key.D, new(big.Int).Sub(q, one))
+	qi = new(big.Int).ModInverse(q, p)
+	if qi == nil {
+		qi = new(big.Int)
+	}
+	return dp, dq, qi
+}
+
+// ecKey returns the public or private key of an "EC" JWK.
+// The point must be on the curve, and the private key must match it.
+func (fields *jwkFields) ecKey(members map[string]json.RawMessage) (interface{}, error) {
+	if fields.Crv == "" {
+		return nil, fmt.Errorf("%w: crv", ErrJWKMissingMember)
+	}
+	curves, ok := jwkCurves[fields.Crv]
+	if !ok {
+		return nil, ErrJWKUnsupportedCurve
+	}
+	curve, ecdhCurve := curves()
+	size := (curve.Params().BitSize + 7) / 8
+	x, err := fields.member("x", fields.X, size)
+	if err != nil {
+		return nil, err
+	}
+	y, err := fields.member("y", fields.Y, size)
+	if err != nil {
+		return nil, err
+	}
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, ErrJWKInvalidKey
+	}
+	publicKey := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if _, ok := members["d"]; !ok {
+		return publicKey, nil
+	}
+	d, err := fields.member("d", fields.D, size)
+	if err != nil {
+		return nil, err
+	}
+	ecdhKey, err := ecdhCurve.NewPrivateKey(d)
+	if err != nil || !bytes.Equal(ecdhKey.PublicKey().Bytes(), point) {
+		return nil, ErrJWKInvalidKey
+	}
+	return &ecdsa.PrivateKey{PublicKey: *publicKey, D: new(big.Int).SetBytes(d)}, nil
+}
+
+// okpKey returns the public or private key of an "OKP" JWK.
+// Only the Ed25519 curve is supported.
+func (fields *jwkFields) okpKey(members map[string]json.RawMessage) (interface{}, error) {
+	if fields.Crv == "" {
+		return nil, fmt.Errorf("%w: crv", ErrJWKMissingMember)
+	}
+	if fields.Crv != "Ed25519" {
+		return nil, ErrJWKUnsupportedCurve
+	}
+	x, err := fields.member("x", fields.X, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := members["d"]; !ok {
+		return ed25519.PublicKey(x), nil
+	}
+	d, err := fields.member("d", fields.D, ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := ed25519.NewKeyFromSeed(d)
+	if !bytes.Equal(privateKey.Public().(ed25519.PublicKey), x) {
+		return nil, ErrJWKInvalidKey
+	}
+	return privateKey, nil
+}
+
+// jwkKeyFields returns the JWK members representing the given key.
+func jwkKeyFields(key interface{}) (*jwkFields, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := key.(type) {
+	case []byte:
+		if len(k) == 0 {
+			return nil, ErrJWKUnsupportedKey
+		}
+		return &jwkFields{Kty: "oct", K: b64(k)}, nil
+	case *rsa.PublicKey:
+		if k == nil || k.N == nil {
+			return nil, ErrJWKUnsupportedKey
+		}
+		return &jwkFields{
+			Kty: "RSA",
+			N:   b64(k.N.Bytes()),
+			E:   b64(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *rsa.PrivateKey:
+		if k == nil {
+			return nil, ErrJWKUnsupportedKey
+		}
+		if len(k.Primes) != 2 {
+			return nil, ErrJWKUnsupportedOtherPrimes
+		}
+		fields, err := jwkKeyFields(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		dp, dq, qi := rsaCRTValues(k)
+		fields.D = b64(k.D.Bytes())
+		fields.P = b64(k.Primes[0].Bytes())
+		fields.Q = b64(k.Primes[1].Bytes())
+		fields.Dp = b64(dp.Bytes())
+		fields.Dq = b64(dq.Bytes())
+		fields.Qi = b64(qi.Bytes())
+		return fields, nil
+	case *ecdsa.PublicKey:
+		if k == nil || k.Curve == nil {
+			return nil, ErrJWKUnsupportedKey
+		}
+		params := k.Curve.Params()
+		if _, ok := jwkCurves[params.Name]; !ok {
+			return nil, ErrJWKUnsupportedCurve
+		}
+		size := (params.BitSize + 7) / 8
+		return &jwkFields{
+			Kty: "EC",
+			Crv: params.Name,
+			X:   b64(k.X.FillBytes(make([]byte, size))),
+			Y:   b64(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *ecdsa.PrivateKey:
+		if k == nil {
+			return nil, ErrJWKUnsupportedKey
+		}
+		fields, err := jwkKeyFields(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		fields.D = b64(k.D.FillBytes(make([]byte, size)))
+		return fields, nil
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return nil, ErrJWKUnsupportedKey
+		}
+		return &jwkFields{Kty: "OKP", Crv: "Ed25519", X: b64(k)}, nil
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return nil, ErrJWKUnsupportedKey
+		}
+		return &jwkFields{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   b64(k.Public().(ed25519.PublicKey)),
+			D:   b64(k.Seed()),
+		}, nil
+	}
+	return nil, ErrJWKUnsupportedKey
+}
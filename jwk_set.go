@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// JWKSet represents a JWK Set (source: https://tools.ietf.org/html/rfc7517#section-5).
+//
+// A JWKSet is safe for concurrent use by multiple goroutines as long as
+// its keys aren't modified.
+type JWKSet struct {
+	Keys []*JWK
+}
+
+// jwkSetFields is the JSON representation of a JWKSet.
+type jwkSetFields struct {
+	Keys []json.RawMessage `json:"keys"`
+}
+
+// ParseJWKSet parses the JSON representation of a JWK Set.
+func ParseJWKSet(data []byte) (*JWKSet, error) {
+	set := &JWKSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// NewWithJWKSet returns a JWT which validates tokens with the keys of the set,
+// selected by the "kid" and "alg" headers of each token. The algorithms the
+// keys may be used with are allowed, see JWKSet.Algorithms.
+func NewWithJWKSet(set *JWKSet) JWT {
+	return NewWithKeyFunc(set.KeyFunc(), set.Algorithms()...)
+}
+
+// MarshalJSON encodes the keys of the set.
+func (set JWKSet) MarshalJSON() ([]byte, error) {
+	keys := set.Keys
+	if keys == nil {
+		keys = []*JWK{}
+	}
+	return json.Marshal(struct {
+		Keys []*JWK `json:"keys"`
+	}{Keys: keys})
+}
+
+// UnmarshalJSON decodes the keys of the set.
+//
+// As recommended by RFC 7517 §5, the keys which aren't understood are
+// skipped, e.g. the keys of unsupported types or curves and the invalid
+// ones, so a single odd key doesn't make the other keys unusable. Only
+// a malformed set document fails decoding.
+func (set *JWKSet) UnmarshalJSON(data []byte) error {
+	var fields jwkSetFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if fields.Keys == nil {
+		return fmt.Errorf("%w: keys", ErrJWKMissingMember)
+	}
+	keys := make([]*JWK, 0, len(fields.Keys))
+	for _, raw := range fields.Keys {
+		jwk, err := ParseJWK(raw)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	set.Keys = keys
+	return nil
+}
+
+// Lookup returns the first key of the set which matches the given "kid",
+// "alg" and "use", any of which matches every key if it is empty.
+//
+// A key without "alg" matches the algorithms its key type can be used with,
+// and a key without "use" or "key_ops" matches any use.
+func (set *JWKSet) Lookup(kid, alg, use string) (*JWK, error) {
+	for _, jwk := range set.Keys {
+		if jwk.matches(kid, alg, use) {
+			return jwk, nil
+		}
+	}
+	return nil, ErrJWKSetKeyNotFound
+}
+
+// KeyFunc returns a KeyFunc which looks the signature verification key
+// of a token up by its "kid" and "alg" headers.
+func (set *JWKSet) KeyFunc() KeyFunc {
+	return func(header *Header, _ *Claims) (interface{}, error) {
+		jwk, err := set.Lookup(header.Kid, header.Alg, "sig")
+		if err != nil {
+			return nil, err
+		}
+		return jwk.VerificationKey(), nil
+	}
+}
+
+// Algorithms returns the sorted list of the registered signing algorithms
+// the signature keys of the set may be used with.
+func (set *JWKSet) Algorithms() []string {
+	seen := make(map[string]bool)
+	for _, jwk := range set.Keys {
+		for alg := range jwkAlgorithms {
+			if jwk.matches("", alg, "sig") {
+				seen[alg] = true
+			}
+		}
+		if jwk.Algorithm != "" && jwk.matches("", jwk.Algorithm, "sig") {
+			if _, err := GetSigningMethod(jwk.Algorithm); err == nil {
+				seen[jwk.Algorithm] = true
+			}
+		}
+	}
+	algs := make([]string, 0, len(seen))
+	for alg := range seen {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// matches reports whether the key matches the given "kid", "alg" and "use".
+func (jwk *JWK) matches(kid, alg, use string) bool {
+	if kid != "" && jwk.KeyID != kid {
+		return false
+	}
+	if use != "" && !jwk.usableFor(use) {
+		return false
+	}
+	if alg == "" {
+		return true
+	}
+	if jwk.Algorithm != "" {
+		return jwk.Algorithm == alg
+	}
+	keyType, ok := jwkAlgorithms[alg]
+	if !ok {
+		return false
+	}
+	return keyType[0] == jwk.KeyType() && (keyType[1] == "" || keyType[1] == jwk.curve())
+}
+
+// usableFor reports whether the "use" and "key_ops" of the key allow the given use.
+func (jwk *JWK) usableFor(use string) bool {
+	if jwk.Use != "" && jwk.Use != use {
+		return false
+	}
+	for _, op := range jwk.KeyOps {
+		if jwkKeyOps[op] == use {
+			return true
+		}
+	}
+	return len(jwk.KeyOps) == 0
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type jwkSetTestKeys struct {
+	rsa *JWK
+	ec  *ecdsa.PrivateKey
+	ed  ed25519.PrivateKey
+	set []byte
+}
+
+// newJWKSetTestKeys returns the keys of a JWK Set along with its JSON
+// representation, which contains public keys only and unsupported ones.
+func newJWKSetTestKeys(t *testing.T) *jwkSetTestKeys {
+	rsaKey := rsaTestKey(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	keys := &jwkSetTestKeys{ec: ecKey, ed: edKey}
+	keys.rsa = &JWK{Key: &rsaKey.PublicKey, KeyID: "rsa", Algorithm: "RS256", Use: "sig"}
+	set := JWKSet{Keys: []*JWK{
+		keys.rsa,
+		{Key: &ecKey.PublicKey, KeyID: "ec"},
+		{Key: edKey.Public(), KeyID: "ed", KeyOps: []string{"verify"}},
+		{Key: &rsaKey.PublicKey, KeyID: "enc", Use: "enc"},
+	}}
+	encoded, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("jwt.newJWKSetTestKeys: %s", err)
+	}
+	unsupported := `{"kty":"OKP","crv":"X25519","x":"hSDwCYkwp1R0i33ctD73Wg2_Og0mOBr066SpjqqbTmo"},{"kty":"foo"},`
+	keys.set = []byte(strings.Replace(string(encoded), "[", "["+unsupported, 1))
+	return keys
+}
+
+func TestParseJWKSet(t *testing.T) {
+	keys := newJWKSetTestKeys(t)
+	set, err := ParseJWKSet(keys.set)
+	if err != nil {
+		t.Fatalf("jwt.TestParseJWKSet: %s", err)
+	}
+	if len(set.Keys) != 4 {
+		t.Fatalf("jwt.TestParseJWKSet: %d keys are parsed", len(set.Keys))
+	}
+	if !reflect.DeepEqual(set.Keys[0], keys.rsa) {
+		t.Errorf("jwt.TestParseJWKSet: %+v != %+v", set.Keys[0], keys.rsa)
+	}
+	if algs := set.Algorithms(); !reflect.DeepEqual(algs, []string{"ES256", "EdDSA", "RS256"}) {
+		t.Errorf("jwt.TestParseJWKSet: invalid algorithms: %v", algs)
+	}
+
+	var data = []struct {
+		kid string
+		alg string
+		use string
+		key string
+	}{
+		{kid: "rsa", alg: "RS256", use: "sig", key: "rsa"},
+		{kid: "rsa", alg: "PS256", use: "sig", key: ""},
+		{kid: "", alg: "ES256", use: "sig", key: "ec"},
+		{kid: "ec", alg: "ES384", use: "", key: ""},
+		{kid: "ed", alg: "EdDSA", use: "sig", key: "ed"},
+		{kid: "ed", alg: "", use: "enc", key: ""},
+		{kid: "enc", alg: "RS256", use: "sig", key: ""},
+		{kid: "enc", alg: "", use: "enc", key: "enc"},
+		{kid: "", alg: "HS256", use: "", key: ""},
+	}
+	for _, d := range data {
+		jwk, err := set.Lookup(d.kid, d.alg, d.use)
+		if d.key == "" {
+			if err != ErrJWKSetKeyNotFound {
+				t.Errorf("jwt.TestParseJWKSet, %s %s %s: %v != %v", d.kid, d.alg, d.use, err, ErrJWKSetKeyNotFound)
+			}
+			continue
+		}
+		if err != nil || jwk.KeyID != d.key {
+			t.Errorf("jwt.TestParseJWKSet, %s %s %s: invalid key: %v, %v", d.kid, d.alg, d.use, jwk, err)
+		}
+	}
+
+	if _, err := ParseJWKSet([]byte(`{}`)); !errors.Is(err, ErrJWKMissingMember) {
+		t.Errorf("jwt.TestParseJWKSet: %v is not %v", err, ErrJWKMissingMember)
+	}
+	for _, malformed := range []string{`[]`, `{"keys":{}}`, `{"keys":[`} {
+		if _, err := ParseJWKSet([]byte(malformed)); err == nil {
+			t.Errorf("jwt.TestParseJWKSet: %s is parsed", malformed)
+		}
+	}
+}
+
+func TestParseJWKSet_SkipInvalid(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	dOnly := jwkMembers(t, rsaKey, map[string]interface{}{"p": nil, "q": nil, "dp": nil, "dq": nil, "qi": nil})
+	custom := jwkMembers(t, &rsaKey.PublicKey, map[string]interface{}{"kid": "custom", "key_ops": []string{"verify", "x-custom"}})
+	valid := jwkMembers(t, &rsaKey.PublicKey, map[string]interface{}{"kid": "valid"})
+	document := `{"keys":[{"kty":"oct"},42,` + string(dOnly) + `,` + string(custom) + `,` + string(valid) + `]}`
+	set, err := ParseJWKSet([]byte(document))
+	if err != nil {
+		t.Fatalf("jwt.TestParseJWKSet_SkipInvalid: %s", err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0].KeyID != "custom" || set.Keys[1].KeyID != "valid" {
+		t.Errorf("jwt.TestParseJWKSet_SkipInvalid: invalid keys: %v", set.Keys)
+	}
+}
+
+func TestNewWithJWKSet(t *testing.T) {
+	keys := newJWKSetTestKeys(t)
+	set, err := ParseJWKSet(keys.set)
+	if err != nil {
+		t.Fatalf("jwt.TestNewWithJWKSet: %s", err)
+	}
+	verifier := NewWithJWKSet(set)
+
+	encode := func(signer JWT, kid string) string {
+		header := signer.NewHeader()
+		header.Kid = kid
+		encoded, err := signer.EncodeWithHeader(header, NewClaims())
+		if err != nil {
+			t.Fatalf("jwt.TestNewWithJWKSet: %s", err)
+		}
+		return encoded
+	}
+	rsaKey := rsaTestKey(t)
+	var data = []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "rsa", token: encode(RsaSha256(rsaKey), "rsa"), err: nil},
+		{name: "ec", token: encode(EsSha256(keys.ec), "ec"), err: nil},
+		{name: "ed", token: encode(Ed25519(keys.ed), "ed"), err: nil},
+		{name: "no kid", token: encode(EsSha256(keys.ec), ""), err: nil},
+		{name: "wrong kid", token: encode(EsSha256(keys.ec), "rsa"), err: ErrJWKSetKeyNotFound},
+		{name: "enc key", token: encode(PsSha256(rsaKey), "enc"), err: ErrTokenAlgorithmNotAllowed},
+		{name: "hmac", token: encode(HmacSha256("secret"), "rsa"), err: ErrTokenAlgorithmNotAllowed},
+	}
+	for _, d := range data {
+		err := verifier.Validate(d.token)
+		if d.err == nil && err != nil {
+			t.Errorf("jwt.TestNewWithJWKSet, %s: %s", d.name, err)
+		}
+		if d.err != nil && !errors.Is(err, d.err) {
+			t.Errorf("jwt.TestNewWithJWKSet, %s: %v is not %v", d.name, err, d.err)
+		}
+	}
+}
+
+func TestJWK_VerificationKey(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	jwk := &JWK{Key: rsaKey}
+	if key, ok := jwk.VerificationKey().(*rsa.PublicKey); !ok || !key.Equal(&rsaKey.PublicKey) {
+		t.Errorf("jwt.TestJWK_VerificationKey: %T is not the public key", jwk.VerificationKey())
+	}
+	jwk = &JWK{Key: []byte("secret")}
+	if key, ok := jwk.VerificationKey().([]byte); !ok || string(key) != "secret" {
+		t.Errorf("jwt.TestJWK_VerificationKey: %T is not the secret", jwk.VerificationKey())
+	}
+}
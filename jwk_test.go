@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// equalKey reports whether the keys are equal.
+func equalKey(a, b interface{}) bool {
+	if k, ok := a.([]byte); ok {
+		other, ok := b.([]byte)
+		return ok && bytes.Equal(k, other)
+	}
+	if k, ok := a.(interface{ Equal(crypto.PrivateKey) bool }); ok {
+		return k.Equal(b)
+	}
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
+
+func TestJWK_MarshalAndParse(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	var data = []struct {
+		key interface{}
+		kty string
+		alg string
+	}{
+		{key: []byte("super-secret-key"), kty: "oct", alg: "HS256"},
+		{key: rsaKey, kty: "RSA", alg: "RS256"},
+		{key: &rsaKey.PublicKey, kty: "RSA", alg: "PS512"},
+		{key: p256, kty: "EC", alg: "ES256"},
+		{key: &p256.PublicKey, kty: "EC", alg: "ES256"},
+		{key: p384, kty: "EC", alg: "ES384"},
+		{key: &p521.PublicKey, kty: "EC", alg: "ES512"},
+		{key: edPrivate, kty: "OKP", alg: "EdDSA"},
+		{key: edPublic, kty: "OKP", alg: "EdDSA"},
+	}
+	for _, d := range data {
+		jwk, err := NewJWK(d.key)
+		if err != nil {
+			t.Errorf("jwt.TestJWK_MarshalAndParse, %s: %s", d.alg, err)
+			continue
+		}
+		if jwk.KeyType() != d.kty {
+			t.Errorf("jwt.TestJWK_MarshalAndParse, %s: %s != %s", d.alg, jwk.KeyType(), d.kty)
+		}
+		jwk.KeyID = "key-1"
+		jwk.Algorithm = d.alg
+		jwk.Use = "sig"
+		jwk.KeyOps = []string{"verify"}
+		encoded, err := json.Marshal(jwk)
+		if err != nil {
+			t.Errorf("jwt.TestJWK_MarshalAndParse, %s: %s", d.alg, err)
+			continue
+		}
+		parsed, err := ParseJWK(encoded)
+		if err != nil {
+			t.Errorf("jwt.TestJWK_MarshalAndParse, %s: %s", d.alg, err)
+			continue
+		}
+		if !equalKey(parsed.Key, d.key) {
+			t.Errorf("jwt.TestJWK_MarshalAndParse, %s: key isn't equal to the original one", d.alg)
+		}
+		if parsed.KeyID != "key-1" || parsed.Algorithm != d.alg || parsed.Use != "sig" || len(parsed.KeyOps) != 1 {
+			t.Errorf("jwt.TestJWK_MarshalAndParse, %s: invalid metadata: %+v", d.alg, parsed)
+		}
+	}
+}
+
+func TestParseJWK_Ed25519(t *testing.T) {
+	// The example of RFC 8037 Appendix A.1.
+	jwk, err := ParseJWK([]byte(`{"kty":"OKP","crv":"Ed25519",
+		"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+		"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+	if err != nil {
+		t.Fatalf("jwt.TestParseJWK_Ed25519: %s", err)
+	}
+	if _, ok := jwk.Key.(ed25519.PrivateKey); !ok {
+		t.Errorf("jwt.TestParseJWK_Ed25519: %T is not ed25519.PrivateKey", jwk.Key)
+	}
+}
+
+// jwkMembers returns the members of the JWK of the given key amended with the given ones,
+// the members with nil values are removed.
+func jwkMembers(t *testing.T, key interface{}, members map[string]interface{}) []byte {
+	jwk, err := NewJWK(key)
+	if err != nil {
+		t.Fatalf("jwt.jwkMembers: %s", err)
+	}
+	encoded, _ := json.Marshal(jwk)
+	var params map[string]interface{}
+	_ = json.Unmarshal(encoded, &params)
+	for name, value := range members {
+		if value == nil {
+			delete(params, name)
+			continue
+		}
+		params[name] = value
+	}
+	encoded, _ = json.Marshal(params)
+	return encoded
+}
+
+func TestParseJWK_Invalid(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherJWK, _ := NewJWK(otherKey)
+	otherEncoded, _ := json.Marshal(otherJWK)
+	var other map[string]interface{}
+	_ = json.Unmarshal(otherEncoded, &other)
+	var data = []struct {
+		name string
+		jwk  []byte
+		err  error
+	}{
+		{name: "no kty", jwk: []byte(`{"k":"c2VjcmV0"}`), err: ErrJWKMissingMember},
+		{name: "unknown kty", jwk: []byte(`{"kty":"foo"}`), err: ErrJWKUnsupportedKeyType},
+		{name: "no k", jwk: []byte(`{"kty":"oct"}`), err: ErrJWKMissingMember},
+		{name: "padded k", jwk: []byte(`{"kty":"oct","k":"c2VjcmV0MQ=="}`), err: ErrJWKInvalidMember},
+		{name: "no n", jwk: []byte(`{"kty":"RSA","e":"AQAB"}`), err: ErrJWKMissingMember},
+		{name: "invalid e", jwk: jwkMembers(t, &rsaKey.PublicKey, map[string]interface{}{"e": "AQ"}), err: ErrJWKInvalidMember},
+		{name: "no qi", jwk: jwkMembers(t, rsaKey, map[string]interface{}{"qi": ""}), err: ErrJWKMissingMember},
+		{name: "invalid dp", jwk: jwkMembers(t, rsaKey, map[string]interface{}{"dp": "AQAB"}), err: ErrJWKInvalidKey},
+		{name: "some primes", jwk: jwkMembers(t, rsaKey, map[string]interface{}{"dq": nil, "qi": nil}), err: ErrJWKMissingMember},
+		{name: "d only", jwk: jwkMembers(t, rsaKey, map[string]interface{}{"p": nil, "q": nil, "dp": nil, "dq": nil, "qi": nil}), err: ErrJWKUnsupportedPrivateKey},
+		{name: "oth", jwk: jwkMembers(t, rsaKey, map[string]interface{}{"oth": []interface{}{}}), err: ErrJWKUnsupportedOtherPrimes},
+		{name: "no crv", jwk: jwkMembers(t, &ecKey.PublicKey, map[string]interface{}{"crv": ""}), err: ErrJWKMissingMember},
+		{name: "unknown crv", jwk: jwkMembers(t, &ecKey.PublicKey, map[string]interface{}{"crv": "P-192"}), err: ErrJWKUnsupportedCurve},
+		{name: "short x", jwk: jwkMembers(t, &ecKey.PublicKey, map[string]interface{}{"x": "AQAB"}), err: ErrJWKInvalidMember},
+		{name: "point not on curve", jwk: jwkMembers(t, &ecKey.PublicKey, map[string]interface{}{"x": other["x"]}), err: ErrJWKInvalidKey},
+		{name: "mismatched d", jwk: jwkMembers(t, ecKey, map[string]interface{}{"d": other["d"]}), err: ErrJWKInvalidKey},
+		{name: "X25519", jwk: []byte(`{"kty":"OKP","crv":"X25519","x":"hSDwCYkwp1R0i33ctD73Wg2_Og0mOBr066SpjqqbTmo"}`), err: ErrJWKUnsupportedCurve},
+		{name: "duplicate key_ops", jwk: []byte(`{"kty":"oct","k":"c2VjcmV0","key_ops":["sign","sign"]}`), err: ErrJWKInvalidKeyOps},
+		{name: "duplicate unknown key_ops", jwk: []byte(`{"kty":"oct","k":"c2VjcmV0","key_ops":["x-custom","x-custom"]}`), err: ErrJWKInvalidKeyOps},
+		{name: "inconsistent use", jwk: []byte(`{"kty":"oct","k":"c2VjcmV0","use":"sig","key_ops":["encrypt"]}`), err: ErrJWKInvalidKeyOps},
+		{name: "alg mismatch", jwk: []byte(`{"kty":"oct","k":"c2VjcmV0","alg":"RS256"}`), err: ErrJWKAlgorithmMismatch},
+		{name: "curve mismatch", jwk: jwkMembers(t, &ecKey.PublicKey, map[string]interface{}{"alg": "ES384"}), err: ErrJWKAlgorithmMismatch},
+	}
+	for _, d := range data {
+		if _, err := ParseJWK(d.jwk); !errors.Is(err, d.err) {
+			t.Errorf("jwt.TestParseJWK_Invalid, %s: %v is not %v", d.name, err, d.err)
+		}
+	}
+}
+
+func TestParseJWK_KeyOps(t *testing.T) {
+	// RFC 7517 §4.3 allows values other than the registered ones.
+	jwk, err := ParseJWK([]byte(`{"kty":"oct","k":"c2VjcmV0","use":"sig","key_ops":["verify","x-custom"]}`))
+	if err != nil {
+		t.Fatalf("jwt.TestParseJWK_KeyOps: %s", err)
+	}
+	if len(jwk.KeyOps) != 2 || jwk.KeyOps[1] != "x-custom" {
+		t.Errorf("jwt.TestParseJWK_KeyOps: invalid key_ops: %v", jwk.KeyOps)
+	}
+}
+
+func TestNewJWK_Unsupported(t *testing.T) {
+	p224, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	var data = []struct {
+		key interface{}
+		err error
+	}{
+		{key: 42, err: ErrJWKUnsupportedKey},
+		{key: "", err: ErrJWKUnsupportedKey},
+		{key: p224, err: ErrJWKUnsupportedCurve},
+	}
+	for _, d := range data {
+		if _, err := NewJWK(d.key); err != d.err {
+			t.Errorf("jwt.TestNewJWK_Unsupported: %v != %v", err, d.err)
+		}
+	}
+	jwk, _ := NewJWK("secret")
+	jwk.Algorithm = "ES256"
+	if _, err := json.Marshal(jwk); !errors.Is(err, ErrJWKAlgorithmMismatch) {
+		t.Errorf("jwt.TestNewJWK_Unsupported: %v is not %v", err, ErrJWKAlgorithmMismatch)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import "fmt"
+
+// KeyFunc returns the key to verify a token with, usually selected by the
+// "kid" and "alg" header parameters. The header and the claims are not
+// verified yet when it is called, so they must not be trusted.
+type KeyFunc func(header *Header, claims *Claims) (interface{}, error)
+
+// NewWithKeyFunc returns a JWT which validates tokens signed with any of the
+// given algorithms using the key returned by keyFunc for each token, e.g. to
+// verify tokens issued under several keys during key rotation.
+//
+// The JWT can't sign tokens. An error returned by keyFunc fails validation
+// with a *ValidationError which wraps both ErrTokenKeyLookup and the error.
+func NewWithKeyFunc(keyFunc KeyFunc, algs ...string) JWT {
+	return JWT{
+		keyFunc:           keyFunc,
+		allowedAlgorithms: algs,
+	}
+}
+
+// keyResolver returns the signing method and the key to verify a token with.
+type keyResolver func(header *Header, claims *Claims) (SigningMethod, interface{}, error)
+
+// lookupKey returns the signing method and the key to verify the token with,
+// method is the one selected by the token's algorithm.
+func (token *JWT) lookupKey(parsed *Token, method SigningMethod) (SigningMethod, interface{}, error) {
+	switch {
+	case token.resolveKey != nil:
+		method, key, err := token.resolveKey(parsed.Header, parsed.Claims)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTokenKeyLookup, err)
+		}
+		return method, key, nil
+	case token.keyFunc != nil:
+		key, err := token.keyFunc(parsed.Header, parsed.Claims)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTokenKeyLookup, err)
+		}
+		return method, key, nil
+	}
+	return method, token.verificationKey, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+var errTestUnknownKid = errors.New("unknown kid")
+
+func TestNewWithKeyFunc(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := map[string]interface{}{
+		"rsa-1": &rsaKey.PublicKey,
+		"ec-1":  &ecKey.PublicKey,
+	}
+	verifier := NewWithKeyFunc(func(header *Header, claims *Claims) (interface{}, error) {
+		key, ok := keys[header.Kid]
+		if !ok {
+			return nil, errTestUnknownKid
+		}
+		return key, nil
+	}, "RS256", "ES256")
+
+	encode := func(signer JWT, kid string) string {
+		header := signer.NewHeader()
+		header.Kid = kid
+		encoded, err := signer.EncodeWithHeader(header, NewClaims())
+		if err != nil {
+			t.Fatalf("jwt.TestNewWithKeyFunc: %s", err)
+		}
+		return encoded
+	}
+	var data = []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "rsa", token: encode(RsaSha256(rsaKey), "rsa-1"), err: nil},
+		{name: "ecdsa", token: encode(EsSha256(ecKey), "ec-1"), err: nil},
+		{name: "wrong key", token: encode(EsSha256(ecKey), "rsa-1"), err: ErrKeyNotECDSAPublic},
+		{name: "unknown kid", token: encode(RsaSha256(rsaKey), "rsa-2"), err: errTestUnknownKid},
+		{name: "not allowed alg", token: encode(RsaSha512(rsaKey), "rsa-1"), err: ErrTokenAlgorithmNotAllowed},
+		{name: "hmac", token: encode(HmacSha256("secret"), "rsa-1"), err: ErrTokenAlgorithmNotAllowed},
+	}
+	for _, d := range data {
+		err := verifier.Validate(d.token)
+		if d.err == nil && err != nil {
+			t.Errorf("jwt.TestNewWithKeyFunc, %s: %s", d.name, err)
+		}
+		if d.err != nil && !errors.Is(err, d.err) {
+			t.Errorf("jwt.TestNewWithKeyFunc, %s: %v is not %v", d.name, err, d.err)
+		}
+	}
+
+	var validationErr *ValidationError
+	err := verifier.Validate(data[3].token)
+	if !errors.Is(err, ErrTokenKeyLookup) || !errors.As(err, &validationErr) || !validationErr.Has(CheckKey) {
+		t.Errorf("jwt.TestNewWithKeyFunc: %v is not a key lookup failure", err)
+	}
+	if _, err := verifier.Encode(NewClaims()); err != ErrTokenUnableToSign {
+		t.Errorf("jwt.TestNewWithKeyFunc: %v != %v", err, ErrTokenUnableToSign)
+	}
+}
+
+func TestNewWithKeyFunc_NoAllowedAlgorithms(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	encoded, _ := hs256.Encode(NewClaims())
+	verifier := NewWithKeyFunc(func(header *Header, claims *Claims) (interface{}, error) {
+		return []byte("super-secret-key"), nil
+	})
+	if err := verifier.Validate(encoded); !errors.Is(err, ErrTokenAlgorithmNotAllowed) {
+		t.Errorf("jwt.TestNewWithKeyFunc_NoAllowedAlgorithms: %v is not %v", err, ErrTokenAlgorithmNotAllowed)
+	}
+	verifier.SetAllowedAlgorithms("HS256")
+	if err := verifier.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestNewWithKeyFunc_NoAllowedAlgorithms: %s", err)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"sync"
+	"time"
+)
+
+// RingKey is a key of a KeyRing.
+type RingKey struct {
+	// ID is the "kid" header value of the tokens signed with the key.
+	ID string
+
+	// Method is the signing method the key is used with.
+	Method SigningMethod
+
+	// SigningKey is used to sign tokens, it may be nil if the key
+	// is used only to verify them.
+	SigningKey interface{}
+
+	// VerificationKey is used to verify tokens. If it is nil, it is derived
+	// from SigningKey, i.e. the public key of an RSA, ECDSA or Ed25519 key
+	// or the HMAC secret itself.
+	VerificationKey interface{}
+
+	// ActivatesAt is the time the key starts to sign tokens.
+	ActivatesAt time.Time
+
+	// RetiresAt is the time the key stops to sign tokens.
+	// The key is never retired if it is zero.
+	RetiresAt time.Time
+
+	// GracePeriod is how long tokens are verified with the key after it is
+	// retired. It is usually the lifetime of the tokens signed with the key.
+	GracePeriod time.Duration
+}
+
+// active reports whether the key signs tokens at the given time.
+func (key *RingKey) active(now time.Time) bool {
+	if key.SigningKey == nil || now.Before(key.ActivatesAt) {
+		return false
+	}
+	return key.RetiresAt.IsZero() || now.Before(key.RetiresAt)
+}
+
+// verifies reports whether the key verifies tokens at the given time.
+func (key *RingKey) verifies(now time.Time) bool {
+	return key.RetiresAt.IsZero() || now.Before(key.RetiresAt.Add(key.GracePeriod))
+}
+
+// KeyRing signs and validates tokens using a set of rotating keys.
+//
+// Tokens are signed with the active key which was activated most recently,
+// and its ID is set as the "kid" header of the token. They are verified with
+// the key their "kid" refers to, which is accepted from the moment it is added
+// to the ring, so tokens signed by another instance of a service that has
+// activated the key a bit earlier are valid, and until its grace period ends.
+//
+// A KeyRing is safe for concurrent use by multiple goroutines, keys may be
+// added and removed while it signs and validates tokens.
+type KeyRing struct {
+	lock     sync.RWMutex
+	keys     []RingKey
+	clock    Clock
+	verifier JWT
+}
+
+// NewKeyRing returns a KeyRing holding the given keys.
+func NewKeyRing(keys ...RingKey) (*KeyRing, error) {
+	ring := &KeyRing{}
+	ring.verifier = JWT{resolveKey: ring.lookupKey}
+	for _, key := range keys {
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return ring, nil
+}
+
+// Add adds the key to the ring.
+//
+// The key must have a unique ID, a signing method, the algorithm of which
+// is allowed by the ring's verifier then, and a verification key, which may
+// be derived from the signing key.
+func (ring *KeyRing) Add(key RingKey) error {
+	if key.VerificationKey == nil {
+		key.VerificationKey = verificationKeyOf(key.SigningKey)
+	}
+	if key.ID == "" || key.Method == nil || key.VerificationKey == nil {
+		return ErrKeyRingInvalidKey
+	}
+	ring.lock.Lock()
+	defer ring.lock.Unlock()
+	for _, k := range ring.keys {
+		if k.ID == key.ID {
+			return ErrKeyRingDuplicateKey
+		}
+	}
+	ring.keys = append(ring.keys, key)
+	ring.allowAlgorithm(key.Method.Alg())
+	return nil
+}
+
+// verificationKeyOf returns the key which verifies the signatures made with
+// the given signing key, or nil if it can't be derived.
+func verificationKeyOf(signingKey interface{}) interface{} {
+	switch key := signingKey.(type) {
+	case *rsa.PrivateKey:
+		return rsaPublicKey(key)
+	case *ecdsa.PrivateKey:
+		return ecdsaPublicKey(key)
+	case ed25519.PrivateKey:
+		return ed25519PublicKey(key)
+	case []byte, string:
+		return key
+	}
+	return nil
+}
+
+// allowAlgorithm adds alg to the algorithms allowed by the ring's verifier.
+// The list is copied, so the verifiers copied by Parse keep their own list.
+func (ring *KeyRing) allowAlgorithm(alg string) {
+	for _, allowed := range ring.verifier.allowedAlgorithms {
+		if allowed == alg {
+			return
+		}
+	}
+	algs := make([]string, 0, len(ring.verifier.allowedAlgorithms)+1)
+	algs = append(algs, ring.verifier.allowedAlgorithms...)
+	ring.verifier.allowedAlgorithms = append(algs, alg)
+}
+
+// Remove removes the key with the given ID from the ring, e.g. once its grace
+// period ended.
+func (ring *KeyRing) Remove(id string) {
+	ring.lock.Lock()
+	defer ring.lock.Unlock()
+	for i, k := range ring.keys {
+		if k.ID == id {
+			ring.keys = append(ring.keys[:i:i], ring.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetClock sets the clock used to select keys and to issue and validate tokens.
+func (ring *KeyRing) SetClock(clock Clock) {
+	ring.lock.Lock()
+	defer ring.lock.Unlock()
+	ring.clock = clock
+	ring.verifier.SetClock(clock)
+}
+
+// getClock returns the ring's clock.
+func (ring *KeyRing) getClock() Clock {
+	if ring.clock == nil {
+		return SystemClock
+	}
+	return ring.clock
+}
+
+// Verifier returns the JWT which validates the ring's tokens, so that the
+// validation of claims can be configured, e.g. with SetAudience.
+//
+// Just like a JWT, the verifier must not be configured while the ring
+// validates tokens.
+func (ring *KeyRing) Verifier() *JWT {
+	return &ring.verifier
+}
+
+// NewClaims returns a new map representing the claims with "iat" claim value
+// taken from the ring's clock.
+func (ring *KeyRing) NewClaims() *Claims {
+	ring.lock.RLock()
+	defer ring.lock.RUnlock()
+	return NewClaimsWithClock(ring.getClock())
+}
+
+// signer returns a JWT which signs tokens with the current active key
+// along with the key's ID.
+func (ring *KeyRing) signer() (JWT, string, error) {
+	ring.lock.RLock()
+	defer ring.lock.RUnlock()
+	now := ring.getClock().Now()
+	var current *RingKey
+	for i := range ring.keys {
+		key := &ring.keys[i]
+		if key.active(now) && (current == nil || key.ActivatesAt.After(current.ActivatesAt)) {
+			current = key
+		}
+	}
+	if current == nil {
+		return JWT{}, "", ErrKeyRingNoActiveKey
+	}
+	signer := New(current.Method, current.SigningKey, current.VerificationKey)
+	signer.SetClock(ring.clock)
+	return signer, current.ID, nil
+}
+
+// Encode returns an encoded JWT token signed with the current active key.
+func (ring *KeyRing) Encode(payload *Claims) (string, error) {
+	return ring.EncodeWithHeader(&Header{Typ: "JWT"}, payload)
+}
+
+// EncodeCustomClaims returns an encoded JWT token with the given claims as
+// payload signed with the current active key.
+func (ring *KeyRing) EncodeCustomClaims(claims interface{}) (string, error) {
+	return ring.EncodeWithHeader(&Header{Typ: "JWT"}, claims)
+}
+
+// EncodeWithHeader returns an encoded JWT token with the given header and
+// claims signed with the current active key. The "alg" and "kid" of the
+// header are always set to the ones of the key.
+func (ring *KeyRing) EncodeWithHeader(header *Header, claims interface{}) (string, error) {
+	signer, kid, err := ring.signer()
+	if err != nil {
+		return "", err
+	}
+	h := *header
+	h.Kid = kid
+	return signer.EncodeWithHeader(&h, claims)
+}
+
+// lookupKey resolves the signing method and the verification key of the
+// ring's verifier. It returns the ones of the key the token's "kid" refers
+// to, as long as the key is used with the token's algorithm and isn't past
+// its grace period, so the token is verified with the key's own method.
+func (ring *KeyRing) lookupKey(header *Header, _ *Claims) (SigningMethod, interface{}, error) {
+	ring.lock.RLock()
+	defer ring.lock.RUnlock()
+	for _, key := range ring.keys {
+		if key.ID != header.Kid {
+			continue
+		}
+		if key.Method.Alg() != header.Alg {
+			return nil, nil, ErrTokenAlgorithmNotAllowed
+		}
+		if !key.verifies(ring.getClock().Now()) {
+			return nil, nil, ErrKeyRingKeyRetired
+		}
+		return key.Method, key.VerificationKey, nil
+	}
+	return nil, nil, ErrKeyRingUnknownKey
+}
+
+// Parse decodes and validates the token, see JWT.Parse.
+func (ring *KeyRing) Parse(encoded string) (*Token, error) {
+	ring.lock.RLock()
+	verifier := ring.verifier
+	ring.lock.RUnlock()
+	return verifier.Parse(encoded)
+}
+
+// Validate verifies a token's validity. It returns nil if it is valid, and an error if invalid.
+func (ring *KeyRing) Validate(encoded string) error {
+	_, err := ring.DecodeAndValidate(encoded)
+	return err
+}
+
+// DecodeAndValidate returns a map representing the token's claims, and it's valid.
+//
+// If the token is well-formed but invalid, the returned error is a *ValidationError.
+// A malformed token fails with one of the decoding errors, e.g. ErrTokenIsMalformed.
+func (ring *KeyRing) DecodeAndValidate(encoded string) (*Claims, error) {
+	parsed, err := ring.Parse(encoded)
+	if parsed == nil {
+		return nil, err
+	}
+	return parsed.Claims, err
+}
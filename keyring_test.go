@@ -0,0 +1,222 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyRing_Rotation(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	rsaKey := rsaTestKey(t)
+	ring, err := NewKeyRing(
+		RingKey{
+			ID:              "hmac-1",
+			Method:          SigningMethodHS256,
+			SigningKey:      "first-secret",
+			VerificationKey: "first-secret",
+			ActivatesAt:     start,
+			RetiresAt:       start.Add(time.Hour),
+			GracePeriod:     30 * time.Minute,
+		},
+		RingKey{
+			ID:              "rsa-1",
+			Method:          SigningMethodRS256,
+			SigningKey:      rsaKey,
+			VerificationKey: &rsaKey.PublicKey,
+			ActivatesAt:     start.Add(time.Hour),
+		},
+	)
+	if err != nil {
+		t.Fatalf("jwt.TestKeyRing_Rotation: %s", err)
+	}
+	ring.SetClock(clock)
+
+	encode := func(kid string) string {
+		encoded, err := ring.Encode(ring.NewClaims())
+		if err != nil {
+			t.Fatalf("jwt.TestKeyRing_Rotation: %s", err)
+		}
+		parsed, _ := ring.Parse(encoded)
+		if parsed == nil || parsed.Header.Kid != kid {
+			t.Fatalf("jwt.TestKeyRing_Rotation: token isn't signed with %s", kid)
+		}
+		return encoded
+	}
+	first := encode("hmac-1")
+	if err := ring.Validate(first); err != nil {
+		t.Errorf("jwt.TestKeyRing_Rotation: %s", err)
+	}
+
+	clock.Advance(time.Hour)
+	second := encode("rsa-1")
+	for _, encoded := range []string{first, second} {
+		if err := ring.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestKeyRing_Rotation: grace period: %s", err)
+		}
+	}
+
+	clock.Advance(30 * time.Minute)
+	if err := ring.Validate(first); !errors.Is(err, ErrKeyRingKeyRetired) || !errors.Is(err, ErrTokenKeyLookup) {
+		t.Errorf("jwt.TestKeyRing_Rotation: %v is not %v", err, ErrKeyRingKeyRetired)
+	}
+	if err := ring.Validate(second); err != nil {
+		t.Errorf("jwt.TestKeyRing_Rotation: %s", err)
+	}
+
+	ring.Remove("rsa-1")
+	if err := ring.Validate(second); !errors.Is(err, ErrKeyRingUnknownKey) {
+		t.Errorf("jwt.TestKeyRing_Rotation: %v is not %v", err, ErrKeyRingUnknownKey)
+	}
+	if _, err := ring.Encode(ring.NewClaims()); err != ErrKeyRingNoActiveKey {
+		t.Errorf("jwt.TestKeyRing_Rotation: %v != %v", err, ErrKeyRingNoActiveKey)
+	}
+}
+
+func TestKeyRing_Verifier(t *testing.T) {
+	ring, _ := NewKeyRing(RingKey{
+		ID:              "hmac-1",
+		Method:          SigningMethodHS256,
+		SigningKey:      "secret",
+		VerificationKey: "secret",
+	})
+	ring.Verifier().SetAudience("api")
+	claims := ring.NewClaims()
+	claims.Set("aud", "web")
+	encoded, _ := ring.Encode(claims)
+	if err := ring.Validate(encoded); !errors.Is(err, ErrTokenInvalidAudience) {
+		t.Errorf("jwt.TestKeyRing_Verifier: %v is not %v", err, ErrTokenInvalidAudience)
+	}
+
+	// A token signed with the secret of the key using another algorithm.
+	header := &Header{Typ: "JWT", Kid: "hmac-1"}
+	hs512 := HmacSha512("secret")
+	forged, _ := hs512.EncodeWithHeader(header, NewClaims())
+	if err := ring.Validate(forged); !errors.Is(err, ErrTokenAlgorithmNotAllowed) {
+		t.Errorf("jwt.TestKeyRing_Verifier: %v is not %v", err, ErrTokenAlgorithmNotAllowed)
+	}
+}
+
+func TestKeyRing_Add(t *testing.T) {
+	ring, _ := NewKeyRing()
+	var data = []struct {
+		key RingKey
+		err error
+	}{
+		{key: RingKey{ID: "a", Method: SigningMethodHS256, SigningKey: "secret"}, err: nil},
+		{key: RingKey{ID: "a", Method: SigningMethodHS384, SigningKey: "secret"}, err: ErrKeyRingDuplicateKey},
+		{key: RingKey{Method: SigningMethodHS256, SigningKey: "secret"}, err: ErrKeyRingInvalidKey},
+		{key: RingKey{ID: "b", SigningKey: "secret"}, err: ErrKeyRingInvalidKey},
+		{key: RingKey{ID: "c", Method: SigningMethodHS256}, err: ErrKeyRingInvalidKey},
+	}
+	for _, d := range data {
+		if err := ring.Add(d.key); err != d.err {
+			t.Errorf("jwt.TestKeyRing_Add, %s: %v != %v", d.key.ID, err, d.err)
+		}
+	}
+	if _, err := NewKeyRing(data[0].key, data[1].key); err != ErrKeyRingDuplicateKey {
+		t.Errorf("jwt.TestKeyRing_Add: %v != %v", err, ErrKeyRingDuplicateKey)
+	}
+}
+
+func TestKeyRing_DeriveVerificationKey(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	ring, err := NewKeyRing(RingKey{ID: "rsa-1", Method: SigningMethodRS256, SigningKey: rsaKey})
+	if err != nil {
+		t.Fatalf("jwt.TestKeyRing_DeriveVerificationKey: %s", err)
+	}
+	encoded, err := ring.Encode(ring.NewClaims())
+	if err != nil {
+		t.Fatalf("jwt.TestKeyRing_DeriveVerificationKey: %s", err)
+	}
+	if err := ring.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestKeyRing_DeriveVerificationKey: %s", err)
+	}
+	rs256 := New(SigningMethodRS256, nil, &rsaKey.PublicKey)
+	if err := rs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestKeyRing_DeriveVerificationKey: %s", err)
+	}
+}
+
+func TestKeyRing_Concurrent(t *testing.T) {
+	ring, _ := NewKeyRing(RingKey{
+		ID:              "key-0",
+		Method:          SigningMethodHS256,
+		SigningKey:      "secret-0",
+		VerificationKey: "secret-0",
+	})
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			secret := fmt.Sprintf("secret-%d", i)
+			_ = ring.Add(RingKey{
+				ID:              fmt.Sprintf("key-%d", i),
+				Method:          SigningMethodHS384,
+				SigningKey:      secret,
+				VerificationKey: secret,
+				ActivatesAt:     time.Unix(int64(i), 0),
+			})
+		}(i)
+		go func() {
+			defer wg.Done()
+			encoded, err := ring.Encode(NewClaims())
+			if err != nil {
+				t.Errorf("jwt.TestKeyRing_Concurrent: %s", err)
+				return
+			}
+			if err := ring.Validate(encoded); err != nil {
+				t.Errorf("jwt.TestKeyRing_Concurrent: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// shadowSigningMethod is testSigningMethod under another name, which either
+// isn't registered or belongs to another registered method.
+type shadowSigningMethod struct {
+	testSigningMethod
+	name string
+}
+
+func (m shadowSigningMethod) Alg() string {
+	return m.name
+}
+
+func TestKeyRing_KeyMethod(t *testing.T) {
+	for _, name := range []string{"X-UNREGISTERED", "HS256"} {
+		ring, _ := NewKeyRing(RingKey{
+			ID:              "external-1",
+			Method:          shadowSigningMethod{name: name},
+			SigningKey:      "secret",
+			VerificationKey: "secret",
+		})
+		encoded, err := ring.Encode(ring.NewClaims())
+		if err != nil {
+			t.Fatalf("jwt.TestKeyRing_KeyMethod, %s: %s", name, err)
+		}
+		if err := ring.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestKeyRing_KeyMethod, %s: %s", name, err)
+		}
+	}
+}
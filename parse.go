@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+// Token represents a parsed JWT token.
+type Token struct {
+	// Raw is the encoded token.
+	Raw string
+
+	// Segments holds the encoded header, payload and signature.
+	Segments [3]string
+
+	// Header is the decoded header.
+	Header *Header
+
+	// Claims holds the decoded payload.
+	Claims *Claims
+
+	// Signature is the decoded signature.
+	Signature []byte
+
+	// Valid is true if the token passed validation.
+	Valid bool
+}
+
+// signingInput returns the part of the token which is signed.
+func (t *Token) signingInput() string {
+	return t.Segments[0] + "." + t.Segments[1]
+}
+
+// Parse decodes and validates the token.
+//
+// If the token is well-formed but invalid, the parsed token is returned with
+// Valid set to false along with a *ValidationError, so the header and the
+// claims can still be inspected, e.g. for logging. They must not be trusted
+// though.
+func (token *JWT) Parse(encoded string) (*Token, error) {
+	parsed, err := token.decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.validate(parsed); err != nil {
+		return parsed, err
+	}
+	parsed.Valid = true
+	return parsed, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWT_Parse(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := NewClaims()
+	claims.Set("sub", "user")
+	encoded, _ := hs256.Encode(claims)
+
+	parsed, err := hs256.Parse(encoded)
+	if err != nil {
+		t.Fatalf("jwt.TestJWT_Parse: %s", err)
+	}
+	if !parsed.Valid {
+		t.Errorf("jwt.TestJWT_Parse: token is not valid")
+	}
+	if parsed.Raw != encoded {
+		t.Errorf("jwt.TestJWT_Parse: invalid Raw: %s != %s", parsed.Raw, encoded)
+	}
+	if strings.Join(parsed.Segments[:], ".") != encoded {
+		t.Errorf("jwt.TestJWT_Parse: invalid Segments: %v", parsed.Segments)
+	}
+	if parsed.Header.Alg != "HS256" || parsed.Header.Typ != "JWT" {
+		t.Errorf("jwt.TestJWT_Parse: invalid Header: %+v", parsed.Header)
+	}
+	if sub, _ := parsed.Claims.GetString("sub"); sub != "user" {
+		t.Errorf("jwt.TestJWT_Parse: invalid sub: %s", sub)
+	}
+	if len(parsed.Signature) != 32 {
+		t.Errorf("jwt.TestJWT_Parse: invalid signature len: %d != %d", len(parsed.Signature), 32)
+	}
+}
+
+func TestJWT_ParseInvalid(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	hs256.SetClock(clock)
+	claims := hs256.NewClaims()
+	claims.SetTime("exp", clock.Now().Add(time.Minute))
+	encoded, _ := hs256.Encode(claims)
+	clock.Advance(time.Hour)
+
+	parsed, err := hs256.Parse(encoded)
+	if !errors.Is(err, ErrTokenHasExpired) {
+		t.Errorf("jwt.TestJWT_ParseInvalid: %v is not %v", err, ErrTokenHasExpired)
+	}
+	if parsed == nil || parsed.Valid {
+		t.Fatalf("jwt.TestJWT_ParseInvalid: invalid token is returned as valid")
+	}
+	if parsed.Header.Alg != "HS256" {
+		t.Errorf("jwt.TestJWT_ParseInvalid: invalid Alg: %s", parsed.Header.Alg)
+	}
+
+	parsed, err = hs256.Parse("e30.e30.!")
+	if parsed != nil || err != ErrTokenUnableToDecodeB64Signature {
+		t.Errorf("jwt.TestJWT_ParseInvalid: %v != %v", err, ErrTokenUnableToDecodeB64Signature)
+	}
+}
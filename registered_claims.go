@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RegisteredClaims represents the registered claims of a JWT token
+// (source: https://tools.ietf.org/html/rfc7519#section-4.1).
+//
+// It is meant to be embedded into user-defined claims structs:
+//
+//	type UserClaims struct {
+//		jwt.RegisteredClaims
+//		Name  string `json:"name"`
+//		Admin bool   `json:"admin"`
+//	}
+//
+// which are encoded with JWT.EncodeCustomClaims and decoded with
+// JWT.DecodeAndValidateCustomClaims.
+type RegisteredClaims struct {
+	// "iss" (Issuer) Claim.
+	Issuer string `json:"iss,omitempty"`
+
+	// "sub" (Subject) Claim.
+	Subject string `json:"sub,omitempty"`
+
+	// "aud" (Audience) Claim.
+	Audience Audience `json:"aud,omitempty"`
+
+	// "exp" (Expiration Time) Claim.
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+
+	// "nbf" (Not Before) Claim.
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+
+	// "iat" (Issued At) Claim.
+	IssuedAt *NumericDate `json:"iat,omitempty"`
+
+	// "jti" (JWT ID) Claim.
+	ID string `json:"jti,omitempty"`
+}
+
+// NumericDate represents the number of seconds from 1970-01-01T00:00:00Z UTC
+// until the specified UTC date/time, ignoring leap seconds (RFC 7519 §2).
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate returns a new NumericDate truncated to the second.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t.Truncate(time.Second)}
+}
+
+// MarshalJSON encodes the date as the number of seconds since the epoch.
+func (date NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(date.Unix(), 10)), nil
+}
+
+// UnmarshalJSON decodes the date from a number of seconds since the epoch,
+// which may have a fractional part. A quoted number isn't a NumericDate.
+func (date *NumericDate) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return ErrClaimNotNumericDate
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return ErrClaimNotNumericDate
+	}
+	if seconds, err := number.Int64(); err == nil {
+		date.Time = time.Unix(seconds, 0)
+		return nil
+	}
+	value, err := number.Float64()
+	if err != nil || value < math.MinInt64 || value >= math.MaxInt64 {
+		return ErrClaimNotNumericDate
+	}
+	seconds, fraction := math.Modf(value)
+	date.Time = time.Unix(int64(seconds), int64(fraction*1e9))
+	return nil
+}
+
+// Audience represents the "aud" claim which is either a single
+// case-sensitive string or an array of them.
+type Audience []string
+
+// Contains returns if the audience has the given value.
+func (aud Audience) Contains(value string) bool {
+	for _, a := range aud {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON encodes a single audience as a string and several as an array.
+func (aud Audience) MarshalJSON() ([]byte, error) {
+	if len(aud) == 1 {
+		return json.Marshal(aud[0])
+	}
+	return json.Marshal([]string(aud))
+}
+
+// UnmarshalJSON decodes the audience from a string or an array of strings.
+func (aud *Audience) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return ErrClaimNotAudience
+		}
+		*aud = Audience{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return ErrClaimNotAudience
+	}
+	*aud = values
+	return nil
+}
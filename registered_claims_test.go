@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testUserClaims struct {
+	RegisteredClaims
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+var Audience_TestData = []struct {
+	aud      Audience
+	expected string
+}{
+	{aud: Audience{"api"}, expected: `"api"`},
+	{aud: Audience{"api", "web"}, expected: `["api","web"]`},
+}
+
+func TestAudience_MarshalJSON(t *testing.T) {
+	for _, data := range Audience_TestData {
+		actual, err := json.Marshal(data.aud)
+		if err != nil {
+			t.Errorf("jwt.TestAudience_MarshalJSON: %s", err)
+			continue
+		}
+		if string(actual) != data.expected {
+			t.Errorf("jwt.TestAudience_MarshalJSON: %s != %s", actual, data.expected)
+		}
+	}
+}
+
+func TestAudience_UnmarshalJSON(t *testing.T) {
+	for _, data := range Audience_TestData {
+		var aud Audience
+		if err := json.Unmarshal([]byte(data.expected), &aud); err != nil {
+			t.Errorf("jwt.TestAudience_UnmarshalJSON: %s", err)
+			continue
+		}
+		if len(aud) != len(data.aud) || !aud.Contains(data.aud[0]) {
+			t.Errorf("jwt.TestAudience_UnmarshalJSON: %v != %v", aud, data.aud)
+		}
+	}
+	var aud Audience
+	if err := json.Unmarshal([]byte(`42`), &aud); err == nil {
+		t.Errorf("jwt.TestAudience_UnmarshalJSON: func does not return an error")
+	}
+}
+
+func TestNumericDate_JSON(t *testing.T) {
+	date := NewNumericDate(time.Unix(1500000000, 999))
+	actual, _ := json.Marshal(date)
+	if string(actual) != "1500000000" {
+		t.Errorf("jwt.TestNumericDate_JSON: %s != %s", actual, "1500000000")
+	}
+	var decoded NumericDate
+	if err := json.Unmarshal([]byte("1500000000.25"), &decoded); err != nil {
+		t.Fatalf("jwt.TestNumericDate_JSON: %s", err)
+	}
+	if decoded.Unix() != 1500000000 || decoded.Nanosecond() != 250000000 {
+		t.Errorf("jwt.TestNumericDate_JSON: invalid time: %s", decoded)
+	}
+	for _, data := range []string{`"tomorrow"`, `"1500000000"`} {
+		if err := json.Unmarshal([]byte(data), &decoded); err != ErrClaimNotNumericDate {
+			t.Errorf("jwt.TestNumericDate_JSON: %s: %v != %s", data, err, ErrClaimNotNumericDate)
+		}
+	}
+}
+
+func TestJWT_CustomClaims(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := testUserClaims{
+		RegisteredClaims: RegisteredClaims{
+			Issuer:    "auth",
+			Subject:   "user",
+			Audience:  Audience{"api"},
+			ExpiresAt: NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  NewNumericDate(time.Now()),
+		},
+		Name:  "John Doe",
+		Admin: true,
+	}
+	encoded, err := hs256.EncodeCustomClaims(claims)
+	if err != nil {
+		t.Fatalf("jwt.TestJWT_CustomClaims: %s", err)
+	}
+	var decoded testUserClaims
+	if err := hs256.DecodeAndValidateCustomClaims(encoded, &decoded); err != nil {
+		t.Fatalf("jwt.TestJWT_CustomClaims: %s", err)
+	}
+	if decoded.Subject != "user" || decoded.Name != "John Doe" || !decoded.Admin {
+		t.Errorf("jwt.TestJWT_CustomClaims: invalid claims: %+v", decoded)
+	}
+	if !decoded.ExpiresAt.Equal(claims.ExpiresAt.Time) {
+		t.Errorf("jwt.TestJWT_CustomClaims: %s != %s", decoded.ExpiresAt, claims.ExpiresAt)
+	}
+	mapClaims, _ := hs256.Decode(encoded)
+	if aud, _ := mapClaims.GetString("aud"); aud != "api" {
+		t.Errorf("jwt.TestJWT_CustomClaims: invalid aud: %s", aud)
+	}
+}
+
+func TestJWT_CustomClaimsExpired(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := testUserClaims{
+		RegisteredClaims: RegisteredClaims{
+			ExpiresAt: NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}
+	encoded, _ := hs256.EncodeCustomClaims(claims)
+	var decoded testUserClaims
+	if err := hs256.DecodeAndValidateCustomClaims(encoded, &decoded); err == nil {
+		t.Errorf("jwt.TestJWT_CustomClaimsExpired: expired token is valid")
+	}
+	if err := hs256.DecodeCustomClaims(encoded, &decoded); err != nil {
+		t.Errorf("jwt.TestJWT_CustomClaimsExpired: %s", err)
+	}
+}
+
+func TestJWT_CustomClaimsMap(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := NewClaims()
+	claims.Set("sub", "user")
+	encoded, err := hs256.EncodeCustomClaims(claims)
+	if err != nil {
+		t.Fatalf("jwt.TestJWT_CustomClaimsMap: %s", err)
+	}
+	decoded := &Claims{}
+	if err := hs256.DecodeAndValidateCustomClaims(encoded, decoded); err != nil {
+		t.Fatalf("jwt.TestJWT_CustomClaimsMap: %s", err)
+	}
+	if sub, _ := decoded.GetString("sub"); sub != "user" {
+		t.Errorf("jwt.TestJWT_CustomClaimsMap: invalid sub: %s", sub)
+	}
+	if !decoded.Contains("iat") {
+		t.Errorf("jwt.TestJWT_CustomClaimsMap: missing iat")
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJWKSetSize is the maximum size of a fetched JWK Set document.
+const maxJWKSetSize = 1 << 20
+
+// minRefreshWait is the minimum time Start waits between two refreshes.
+const minRefreshWait = time.Second
+
+// RemoteJWKS provides the keys of a JWK Set published at a URL, e.g. by an
+// identity provider which rotates its signing keys.
+//
+// The keys are cached for the time allowed by the Cache-Control max-age or
+// the Expires headers of the response, bounded by the cache TTL limits. When
+// the cache has expired or a token refers to an unknown "kid", the keys are
+// fetched again, but not more often than the refetch interval allows. If the
+// keys can't be fetched, or while they are being fetched by another goroutine,
+// the keys fetched last are used. A set without any usable key is treated as
+// a failed fetch, so it never replaces the keys fetched last.
+//
+// RemoteJWKS is safe for concurrent use by multiple goroutines once it is
+// configured, i.e. its Set* methods must not be called while it is in use.
+type RemoteJWKS struct {
+	url    string
+	client *http.Client
+	clock  Clock
+
+	// minTTL and maxTTL bound the time the keys are cached for.
+	minTTL time.Duration
+	maxTTL time.Duration
+
+	// refetchInterval is the minimum time between two fetches.
+	refetchInterval time.Duration
+
+	// fetchLock serializes fetches.
+	fetchLock sync.Mutex
+
+	// lock guards the fields below.
+	lock      sync.RWMutex
+	set       *JWKSet
+	expiresAt time.Time
+	fetchedAt time.Time
+	err       error
+}
+
+// NewRemoteJWKS returns a RemoteJWKS which fetches the keys from the given URL.
+//
+// By default, the keys are fetched by an http.Client with a 10 seconds
+// timeout, cached for at least a minute and at most a day, for an hour
+// if the response has no cache headers, and fetched at most once a minute.
+func NewRemoteJWKS(url string) *RemoteJWKS {
+	return &RemoteJWKS{
+		url:             url,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		minTTL:          time.Minute,
+		maxTTL:          24 * time.Hour,
+		refetchInterval: time.Minute,
+	}
+}
+
+// SetHTTPClient sets the client used to fetch the keys.
+func (r *RemoteJWKS) SetHTTPClient(client *http.Client) {
+	r.client = client
+}
+
+// SetClock sets the clock used to expire the cached keys.
+func (r *RemoteJWKS) SetClock(clock Clock) {
+	r.clock = clock
+}
+
+// SetCacheTTL sets the minimum and the maximum time the keys are cached for
+// regardless of the cache headers of the response.
+func (r *RemoteJWKS) SetCacheTTL(min, max time.Duration) {
+	r.minTTL = min
+	r.maxTTL = max
+}
+
+// SetRefetchInterval sets the minimum time between two fetches of the keys,
+// which limits the requests caused by tokens with unknown "kid" values or
+// by an unavailable endpoint.
+func (r *RemoteJWKS) SetRefetchInterval(interval time.Duration) {
+	r.refetchInterval = interval
+}
+
+// now returns the current time of the RemoteJWKS's clock.
+func (r *RemoteJWKS) now() time.Time {
+	if r.clock == nil {
+		return SystemClock.Now()
+	}
+	return r.clock.Now()
+}
+
+// KeyFunc returns a KeyFunc which looks the signature verification key of a
+// token up by its "kid" and "alg" headers, fetching the keys if needed.
+//
+// The algorithms of the keys aren't known in advance, so they must be allowed
+// explicitly, e.g. NewWithKeyFunc(remote.KeyFunc(), "RS256").
+func (r *RemoteJWKS) KeyFunc() KeyFunc {
+	return func(header *Header, _ *Claims) (interface{}, error) {
+		ctx := context.Background()
+		set, err := r.Keys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		jwk, err := set.Lookup(header.Kid, header.Alg, "sig")
+		if err == ErrJWKSetKeyNotFound {
+			// The keys may have been rotated since they were fetched.
+			looked := set
+			set, err = r.update(ctx, func() bool { return r.set == looked })
+			if err != nil {
+				return nil, err
+			}
+			jwk, err = set.Lookup(header.Kid, header.Alg, "sig")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return jwk.VerificationKey(), nil
+	}
+}
+
+// Keys returns the cached keys, fetching them if the cache has expired.
+//
+// The expired keys are returned if they can't be fetched, so an error
+// is returned only if the keys have never been fetched successfully.
+func (r *RemoteJWKS) Keys(ctx context.Context) (*JWKSet, error) {
+	r.lock.RLock()
+	set, expired := r.set, !r.now().Before(r.expiresAt)
+	r.lock.RUnlock()
+	if set != nil && !expired {
+		return set, nil
+	}
+	return r.update(ctx, func() bool {
+		return r.set == nil || !r.now().Before(r.expiresAt)
+	})
+}
+
+// Refresh fetches the keys regardless of the cache and the refetch interval.
+func (r *RemoteJWKS) Refresh(ctx context.Context) error {
+	r.fetchLock.Lock()
+	defer r.fetchLock.Unlock()
+	return r.fetch(ctx)
+}
+
+// Start refreshes the keys in the background each time the cache expires,
+// or once per refetch interval while they can't be fetched, until the
+// context is done. The keys are refreshed at most once a second.
+func (r *RemoteJWKS) Start(ctx context.Context) {
+	go func() {
+		for {
+			wait := r.refetchInterval
+			r.lock.RLock()
+			if r.err == nil && r.set != nil {
+				if ttl := r.expiresAt.Sub(r.now()); ttl > wait {
+					wait = ttl
+				}
+			}
+			if wait < minRefreshWait {
+				wait = minRefreshWait
+			}
+			initial := r.fetchedAt.IsZero()
+			r.lock.RUnlock()
+			if !initial {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			_ = r.Refresh(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+// update fetches the keys if stale reports that the cached keys must be
+// replaced and the refetch interval has passed since the last fetch.
+// It returns the cached keys, which may be stale if they can't be fetched.
+// If another goroutine is fetching the keys, the cached keys are returned
+// without waiting for it, unless no keys have been fetched yet.
+func (r *RemoteJWKS) update(ctx context.Context, stale func() bool) (*JWKSet, error) {
+	if !r.fetchLock.TryLock() {
+		r.lock.RLock()
+		set := r.set
+		r.lock.RUnlock()
+		if set != nil {
+			return set, nil
+		}
+		r.fetchLock.Lock()
+	}
+	defer r.fetchLock.Unlock()
+	r.lock.RLock()
+	fetch := stale() && (r.fetchedAt.IsZero() || !r.now().Before(r.fetchedAt.Add(r.refetchInterval)))
+	r.lock.RUnlock()
+	if fetch {
+		_ = r.fetch(ctx)
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.set == nil {
+		return nil, r.err
+	}
+	return r.set, nil
+}
+
+// fetch fetches the keys and caches them, or records the error.
+// It must be called with fetchLock held.
+func (r *RemoteJWKS) fetch(ctx context.Context) error {
+	now := r.now()
+	set, ttl, err := r.get(ctx, now)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.fetchedAt = now
+	r.err = err
+	if err != nil {
+		return err
+	}
+	r.set = set
+	r.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// get requests the JWK Set and returns it along with the time to cache it for.
+func (r *RemoteJWKS) get(ctx context.Context, now time.Time) (*JWKSet, time.Duration, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrJWKSFetch, err)
+	}
+	request.Header.Set("Accept", "application/jwk-set+json, application/json")
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrJWKSFetch, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("%w: %s", ErrJWKSFetch, response.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxJWKSetSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrJWKSFetch, err)
+	}
+	set, err := ParseJWKSet(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrJWKSFetch, err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, 0, fmt.Errorf("%w: no usable keys", ErrJWKSFetch)
+	}
+	return set, r.cacheTTL(response.Header, now), nil
+}
+
+// cacheTTL returns the time to cache a response with the given headers for.
+func (r *RemoteJWKS) cacheTTL(header http.Header, now time.Time) time.Duration {
+	ttl := time.Hour
+	if maxAge, ok := cacheMaxAge(header.Get("Cache-Control")); ok {
+		ttl = maxAge
+	} else if expires := header.Get("Expires"); expires != "" {
+		ttl = 0
+		if expiresAt, err := http.ParseTime(expires); err == nil {
+			if date, err := http.ParseTime(header.Get("Date")); err == nil {
+				now = date
+			}
+			ttl = expiresAt.Sub(now)
+		}
+	}
+	if ttl < r.minTTL {
+		ttl = r.minTTL
+	}
+	if ttl > r.maxTTL {
+		ttl = r.maxTTL
+	}
+	return ttl
+}
+
+// cacheMaxAge returns the max-age of the Cache-Control header value,
+// which is zero if the response must not be cached.
+func cacheMaxAge(cacheControl string) (time.Duration, bool) {
+	maxAge, ok := time.Duration(0), false
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return 0, true
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				seconds = 0
+			}
+			if seconds > int64(math.MaxInt64/time.Second) {
+				seconds = int64(math.MaxInt64 / time.Second)
+			}
+			maxAge, ok = time.Duration(seconds)*time.Second, true
+		}
+	}
+	return maxAge, ok
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksTestServer serves a JWK Set and counts the requests.
+type jwksTestServer struct {
+	lock         sync.Mutex
+	set          []byte
+	status       int
+	cacheControl string
+	hits         int
+}
+
+func (s *jwksTestServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hits++
+	if s.cacheControl != "" {
+		w.Header().Set("Cache-Control", s.cacheControl)
+	}
+	w.WriteHeader(s.status)
+	_, _ = w.Write(s.set)
+}
+
+// serve sets the keys and the status of the response.
+func (s *jwksTestServer) serve(t *testing.T, status int, keys ...*ecdsa.PrivateKey) {
+	set := JWKSet{Keys: []*JWK{}}
+	for i, key := range keys {
+		set.Keys = append(set.Keys, &JWK{Key: &key.PublicKey, KeyID: string(rune('a' + i)), Algorithm: "ES256"})
+	}
+	encoded, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("jwt.jwksTestServer: %s", err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.set = encoded
+	s.status = status
+}
+
+func (s *jwksTestServer) requests() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.hits
+}
+
+// encodeWithKid returns a token signed with the key along with the given "kid".
+func encodeWithKid(t *testing.T, key *ecdsa.PrivateKey, kid string) string {
+	signer := EsSha256(key)
+	header := signer.NewHeader()
+	header.Kid = kid
+	encoded, err := signer.EncodeWithHeader(header, NewClaims())
+	if err != nil {
+		t.Fatalf("jwt.encodeWithKid: %s", err)
+	}
+	return encoded
+}
+
+func TestRemoteJWKS_Cache(t *testing.T) {
+	first, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{cacheControl: "public, max-age=300"}
+	handler.serve(t, http.StatusOK, first)
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	remote := NewRemoteJWKS(server.URL)
+	remote.SetHTTPClient(server.Client())
+	remote.SetClock(clock)
+	for i := 0; i < 3; i++ {
+		if _, err := remote.Keys(context.Background()); err != nil {
+			t.Fatalf("jwt.TestRemoteJWKS_Cache: %s", err)
+		}
+	}
+	if handler.requests() != 1 {
+		t.Errorf("jwt.TestRemoteJWKS_Cache: %d requests != 1", handler.requests())
+	}
+	clock.Advance(5 * time.Minute)
+	set, err := remote.Keys(context.Background())
+	if err != nil || len(set.Keys) != 1 {
+		t.Fatalf("jwt.TestRemoteJWKS_Cache: %v, %v", set, err)
+	}
+	if handler.requests() != 2 {
+		t.Errorf("jwt.TestRemoteJWKS_Cache: %d requests != 2", handler.requests())
+	}
+}
+
+func TestRemoteJWKS_CacheTTL(t *testing.T) {
+	remote := NewRemoteJWKS("")
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	date := now.Add(-time.Hour).Format(http.TimeFormat)
+	var data = []struct {
+		header http.Header
+		ttl    time.Duration
+	}{
+		{header: http.Header{}, ttl: time.Hour},
+		{header: http.Header{"Cache-Control": {"max-age=300"}}, ttl: 5 * time.Minute},
+		{header: http.Header{"Cache-Control": {"public, max-age=600, must-revalidate"}}, ttl: 10 * time.Minute},
+		{header: http.Header{"Cache-Control": {"max-age=10"}}, ttl: time.Minute},
+		{header: http.Header{"Cache-Control": {"max-age=99999999999999"}}, ttl: 24 * time.Hour},
+		{header: http.Header{"Cache-Control": {"max-age=600, no-cache"}}, ttl: time.Minute},
+		{header: http.Header{"Cache-Control": {"no-store"}}, ttl: time.Minute},
+		{header: http.Header{"Cache-Control": {"max-age=300"}, "Expires": {now.Add(2 * time.Hour).Format(http.TimeFormat)}}, ttl: 5 * time.Minute},
+		{header: http.Header{"Expires": {now.Add(2 * time.Hour).Format(http.TimeFormat)}}, ttl: 2 * time.Hour},
+		{header: http.Header{"Expires": {now.Add(2 * time.Hour).Format(http.TimeFormat)}, "Date": {date}}, ttl: 3 * time.Hour},
+		{header: http.Header{"Expires": {"0"}}, ttl: time.Minute},
+	}
+	for _, d := range data {
+		if ttl := remote.cacheTTL(d.header, now); ttl != d.ttl {
+			t.Errorf("jwt.TestRemoteJWKS_CacheTTL, %v: %s != %s", d.header, ttl, d.ttl)
+		}
+	}
+}
+
+func TestRemoteJWKS_UnknownKid(t *testing.T) {
+	first, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	second, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{}
+	handler.serve(t, http.StatusOK, first)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	remote := NewRemoteJWKS(server.URL)
+	remote.SetClock(clock)
+	verifier := NewWithKeyFunc(remote.KeyFunc(), "ES256")
+	if err := verifier.Validate(encodeWithKid(t, first, "a")); err != nil {
+		t.Fatalf("jwt.TestRemoteJWKS_UnknownKid: %s", err)
+	}
+
+	// The provider rotates its keys.
+	handler.serve(t, http.StatusOK, first, second)
+	clock.Advance(time.Minute)
+	if err := verifier.Validate(encodeWithKid(t, second, "b")); err != nil {
+		t.Errorf("jwt.TestRemoteJWKS_UnknownKid: %s", err)
+	}
+	if handler.requests() != 2 {
+		t.Errorf("jwt.TestRemoteJWKS_UnknownKid: %d requests != 2", handler.requests())
+	}
+
+	for i := 0; i < 5; i++ {
+		err := verifier.Validate(encodeWithKid(t, second, "c"))
+		if !errors.Is(err, ErrJWKSetKeyNotFound) || !errors.Is(err, ErrTokenKeyLookup) {
+			t.Errorf("jwt.TestRemoteJWKS_UnknownKid: %v is not %v", err, ErrJWKSetKeyNotFound)
+		}
+	}
+	if handler.requests() != 2 {
+		t.Errorf("jwt.TestRemoteJWKS_UnknownKid: refetch isn't rate limited: %d requests", handler.requests())
+	}
+	clock.Advance(time.Minute)
+	_ = verifier.Validate(encodeWithKid(t, second, "c"))
+	if handler.requests() != 3 {
+		t.Errorf("jwt.TestRemoteJWKS_UnknownKid: %d requests != 3", handler.requests())
+	}
+}
+
+func TestRemoteJWKS_Unavailable(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{}
+	handler.serve(t, http.StatusServiceUnavailable)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	remote := NewRemoteJWKS(server.URL)
+	remote.SetClock(clock)
+	if _, err := remote.Keys(context.Background()); !errors.Is(err, ErrJWKSFetch) {
+		t.Errorf("jwt.TestRemoteJWKS_Unavailable: %v is not %v", err, ErrJWKSFetch)
+	}
+
+	handler.serve(t, http.StatusOK, key)
+	if err := remote.Refresh(context.Background()); err != nil {
+		t.Fatalf("jwt.TestRemoteJWKS_Unavailable: %s", err)
+	}
+	handler.serve(t, http.StatusInternalServerError)
+	clock.Advance(2 * time.Hour)
+	verifier := NewWithKeyFunc(remote.KeyFunc(), "ES256")
+	if err := verifier.Validate(encodeWithKid(t, key, "a")); err != nil {
+		t.Errorf("jwt.TestRemoteJWKS_Unavailable: stale keys aren't used: %s", err)
+	}
+	if handler.requests() != 3 {
+		t.Errorf("jwt.TestRemoteJWKS_Unavailable: %d requests != 3", handler.requests())
+	}
+	if err := remote.Refresh(context.Background()); !errors.Is(err, ErrJWKSFetch) {
+		t.Errorf("jwt.TestRemoteJWKS_Unavailable: %v is not %v", err, ErrJWKSFetch)
+	}
+}
+
+func TestRemoteJWKS_Start(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{cacheControl: "max-age=0"}
+	handler.serve(t, http.StatusOK, key)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	remote := NewRemoteJWKS(server.URL)
+	remote.SetCacheTTL(0, 0)
+	remote.SetRefetchInterval(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	remote.Start(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for handler.requests() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if handler.requests() < 2 {
+		t.Fatalf("jwt.TestRemoteJWKS_Start: keys aren't refreshed: %d requests", handler.requests())
+	}
+	if _, err := remote.Keys(context.Background()); err != nil {
+		t.Errorf("jwt.TestRemoteJWKS_Start: %s", err)
+	}
+}
+
+func TestRemoteJWKS_StartWithoutInterval(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{cacheControl: "max-age=0"}
+	handler.serve(t, http.StatusOK, key)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	remote := NewRemoteJWKS(server.URL)
+	remote.SetCacheTTL(0, 0)
+	remote.SetRefetchInterval(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remote.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+	if handler.requests() > 1 {
+		t.Errorf("jwt.TestRemoteJWKS_StartWithoutInterval: keys are refreshed in a loop: %d requests", handler.requests())
+	}
+}
+
+func TestRemoteJWKS_EmptySet(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{}
+	handler.serve(t, http.StatusOK, key)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	remote := NewRemoteJWKS(server.URL)
+	if err := remote.Refresh(context.Background()); err != nil {
+		t.Fatalf("jwt.TestRemoteJWKS_EmptySet: %s", err)
+	}
+	handler.serve(t, http.StatusOK)
+	if err := remote.Refresh(context.Background()); !errors.Is(err, ErrJWKSFetch) {
+		t.Errorf("jwt.TestRemoteJWKS_EmptySet: %v is not %v", err, ErrJWKSFetch)
+	}
+	verifier := NewWithKeyFunc(remote.KeyFunc(), "ES256")
+	if err := verifier.Validate(encodeWithKid(t, key, "a")); err != nil {
+		t.Errorf("jwt.TestRemoteJWKS_EmptySet: stale keys aren't used: %s", err)
+	}
+}
+
+func TestRemoteJWKS_FetchInProgress(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := &jwksTestServer{}
+	handler.serve(t, http.StatusOK, key)
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if handler.requests() > 0 {
+			select {
+			case arrived <- struct{}{}:
+			default:
+			}
+			<-release
+		}
+		handler.ServeHTTP(w, request)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	clock := NewFakeClock(time.Now())
+	remote := NewRemoteJWKS(server.URL)
+	remote.SetClock(clock)
+	if _, err := remote.Keys(context.Background()); err != nil {
+		t.Fatalf("jwt.TestRemoteJWKS_FetchInProgress: %s", err)
+	}
+	clock.Advance(2 * time.Hour)
+	go func() {
+		_, _ = remote.Keys(context.Background())
+	}()
+	<-arrived
+
+	// The keys are being fetched by the goroutine above, which hangs.
+	done := make(chan error, 1)
+	go func() {
+		set, err := remote.Keys(context.Background())
+		if err == nil && len(set.Keys) != 1 {
+			err = errors.New("stale keys aren't returned")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("jwt.TestRemoteJWKS_FetchInProgress: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("jwt.TestRemoteJWKS_FetchInProgress: waits for the fetch in progress")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// SigningMethodRSA implements the RSASSA-PKCS1-v1_5 signing methods.
+// It signs with a *rsa.PrivateKey and verifies with a *rsa.PublicKey.
+type SigningMethodRSA struct {
+	Name string
+	Hash crypto.Hash
+}
+
+var (
+	SigningMethodRS256 = &SigningMethodRSA{Name: "RS256", Hash: crypto.SHA256}
+	SigningMethodRS384 = &SigningMethodRSA{Name: "RS384", Hash: crypto.SHA384}
+	SigningMethodRS512 = &SigningMethodRSA{Name: "RS512", Hash: crypto.SHA512}
+)
+
+func init() {
+	RegisterSigningMethod(SigningMethodRS256)
+	RegisterSigningMethod(SigningMethodRS384)
+	RegisterSigningMethod(SigningMethodRS512)
+}
+
+// Alg returns the "alg" header value of the method.
+func (m *SigningMethodRSA) Alg() string {
+	return m.Name
+}
+
+// Sign signs the data using RSASSA-PKCS1-v1_5 with the given private key.
+func (m *SigningMethodRSA) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok || privateKey == nil {
+		return nil, ErrKeyNotRSAPrivate
+	}
+	hashed, err := digest(m.Hash, data)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.SignPKCS1v15(rand.Reader, privateKey, m.Hash, hashed)
+}
+
+// Verify verifies a RSASSA-PKCS1-v1_5 signature of the data using the given public key.
+func (m *SigningMethodRSA) Verify(data, signature []byte, key interface{}) error {
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok || publicKey == nil {
+		return ErrKeyNotRSAPublic
+	}
+	hashed, err := digest(m.Hash, data)
+	if err != nil {
+		return err
+	}
+	if err := rsa.VerifyPKCS1v15(publicKey, m.Hash, hashed, signature); err != nil {
+		return ErrTokenInvalidSignature
+	}
+	return nil
+}
+
+// rsaPublicKey returns the public part of the given private key or nil if the key is nil.
+func rsaPublicKey(key *rsa.PrivateKey) interface{} {
+	if key == nil {
+		return nil
+	}
+	return &key.PublicKey
+}
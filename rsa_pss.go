@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// SigningMethodRSAPSS implements the RSASSA-PSS signing methods.
+// It signs with a *rsa.PrivateKey and verifies with a *rsa.PublicKey.
+type SigningMethodRSAPSS struct {
+	Name string
+	Hash crypto.Hash
+
+	// SaltLength is the salt length used to sign and verify signatures.
+	//
+	// RFC 7518 §3.5 requires the salt to be of the same size as the hash output,
+	// which is what rsa.PSSSaltLengthEqualsHash means. rsa.PSSSaltLengthAuto is
+	// only meaningful for verification, so signing falls back to the hash size.
+	SaltLength int
+}
+
+var (
+	SigningMethodPS256 = &SigningMethodRSAPSS{Name: "PS256", Hash: crypto.SHA256, SaltLength: rsa.PSSSaltLengthEqualsHash}
+	SigningMethodPS384 = &SigningMethodRSAPSS{Name: "PS384", Hash: crypto.SHA384, SaltLength: rsa.PSSSaltLengthEqualsHash}
+	SigningMethodPS512 = &SigningMethodRSAPSS{Name: "PS512", Hash: crypto.SHA512, SaltLength: rsa.PSSSaltLengthEqualsHash}
+)
+
+func init() {
+	RegisterSigningMethod(SigningMethodPS256)
+	RegisterSigningMethod(SigningMethodPS384)
+	RegisterSigningMethod(SigningMethodPS512)
+}
+
+// Alg returns the "alg" header value of the method.
+func (m *SigningMethodRSAPSS) Alg() string {
+	return m.Name
+}
+
+// Sign signs the data using RSASSA-PSS with the given private key.
+func (m *SigningMethodRSAPSS) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok || privateKey == nil {
+		return nil, ErrKeyNotRSAPrivate
+	}
+	hashed, err := digest(m.Hash, data)
+	if err != nil {
+		return nil, err
+	}
+	saltLength := m.SaltLength
+	if saltLength == rsa.PSSSaltLengthAuto {
+		saltLength = rsa.PSSSaltLengthEqualsHash
+	}
+	return rsa.SignPSS(rand.Reader, privateKey, m.Hash, hashed, &rsa.PSSOptions{
+		SaltLength: saltLength,
+	})
+}
+
+// Verify verifies a RSASSA-PSS signature of the data using the given public key.
+//
+// The signature is randomized, so it is checked by rsa.VerifyPSS instead of
+// being compared with a fresh one.
+func (m *SigningMethodRSAPSS) Verify(data, signature []byte, key interface{}) error {
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok || publicKey == nil {
+		return ErrKeyNotRSAPublic
+	}
+	hashed, err := digest(m.Hash, data)
+	if err != nil {
+		return err
+	}
+	if err := rsa.VerifyPSS(publicKey, m.Hash, hashed, signature, &rsa.PSSOptions{
+		SaltLength: m.SaltLength,
+	}); err != nil {
+		return ErrTokenInvalidSignature
+	}
+	return nil
+}
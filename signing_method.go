@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"crypto"
+	"sync"
+)
+
+// SigningMethod signs tokens and verifies their signatures.
+//
+// Implementations must be safe for concurrent use: Sign and Verify keep their
+// state, such as hash state, per call.
+//
+// The type of the key depends on the method, e.g. HMAC methods use []byte
+// while RSA methods use *rsa.PrivateKey to sign and *rsa.PublicKey to verify.
+type SigningMethod interface {
+	// Alg returns the "alg" header value of the method.
+	Alg() string
+
+	// Sign returns the signature of the data made with the given key.
+	Sign(data []byte, key interface{}) ([]byte, error)
+
+	// Verify returns nil if the signature of the data is valid for the given key.
+	Verify(data, signature []byte, key interface{}) error
+}
+
+var (
+	signingMethods     = map[string]SigningMethod{}
+	signingMethodsLock sync.RWMutex
+)
+
+// RegisterSigningMethod registers the signing method under its "alg" value.
+// A method registered earlier for the same value is replaced.
+func RegisterSigningMethod(method SigningMethod) {
+	signingMethodsLock.Lock()
+	defer signingMethodsLock.Unlock()
+	signingMethods[method.Alg()] = method
+}
+
+// GetSigningMethod returns the signing method registered for the given "alg" value.
+func GetSigningMethod(alg string) (SigningMethod, error) {
+	signingMethodsLock.RLock()
+	defer signingMethodsLock.RUnlock()
+	method, ok := signingMethods[alg]
+	if !ok {
+		return nil, ErrSigningMethodNotRegistered
+	}
+	return method, nil
+}
+
+// digest returns the hash of the given data computed with the given hash function.
+func digest(hash crypto.Hash, data []byte) ([]byte, error) {
+	if !hash.Available() {
+		return nil, ErrHashUnavailable
+	}
+	hasher := hash.New()
+	if _, err := hasher.Write(data); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
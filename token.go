@@ -17,64 +17,267 @@
 package jwt
 
 import (
-	"crypto/hmac"
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"hash"
+	"io"
+	"regexp"
 	"strings"
 	"time"
 )
 
 // JWT is used to sign and validate a token.
+//
+// A JWT is safe for concurrent use by multiple goroutines once it is configured,
+// i.e. its Set* and Require* methods must not be called while it signs or
+// validates tokens.
 type JWT struct {
-	signingHash hash.Hash
-	algorithm   string
+	method          SigningMethod
+	signingKey      interface{}
+	verificationKey interface{}
+
+	// keyFunc resolves the verification key of each token if it isn't nil.
+	keyFunc KeyFunc
+
+	// resolveKey resolves both the signing method and the verification key
+	// of each token if it isn't nil, e.g. for a KeyRing.
+	resolveKey keyResolver
+
+	// allowedAlgorithms is the list of "alg" header values accepted during validation.
+	allowedAlgorithms []string
+
+	// audience is the list of acceptable "aud" claim values.
+	audience []string
+
+	// issuers is the list of trusted "iss" claim values.
+	issuers []string
+
+	// requireSubject and subjectPattern describe the required "sub" claim.
+	requireSubject bool
+	subjectPattern *regexp.Regexp
+
+	// requireID makes the "jti" claim required.
+	requireID bool
+
+	// leeway is the clock skew tolerated when time claims are validated.
+	leeway time.Duration
+
+	// clock provides the current time, SystemClock is used if it is nil.
+	clock Clock
+
+	// maxAge is the maximum age of a token based on its "iat" claim.
+	maxAge time.Duration
+
+	// reportAllErrors makes validation run every claim check.
+	reportAllErrors bool
+
+	// criticalHeaders is the set of header parameters which may be listed in "crit".
+	criticalHeaders []string
+
+	// useNumber makes decoding keep the numbers of the payload as json.Number.
+	useNumber bool
+}
+
+// New returns a JWT which signs tokens with the signing key and validates
+// them with the verification key using the given method. The signing key
+// may be nil if the JWT is used only to validate tokens.
+func New(method SigningMethod, signingKey, verificationKey interface{}) JWT {
+	return JWT{
+		method:          method,
+		signingKey:      signingKey,
+		verificationKey: verificationKey,
+	}
 }
 
 // NewHeader returns a new Header object.
 func (token *JWT) NewHeader() *Header {
 	return &Header{
 		Typ: "JWT",
-		Alg: token.algorithm,
+		Alg: token.alg(),
 	}
 }
 
-func (token *JWT) sum(data []byte) []byte {
-	return token.signingHash.Sum(data)
+// alg returns the algorithm of the JWT's signing method.
+func (token *JWT) alg() string {
+	if token.method == nil {
+		return ""
+	}
+	return token.method.Alg()
+}
+
+// SetSaltLength sets the salt length used to sign and verify RSASSA-PSS signatures.
+//
+// PS256, PS384 and PS512 use a salt of the same size as the hash output as
+// required by RFC 7518 §3.5. Use rsa.PSSSaltLengthAuto to accept signatures
+// produced with any salt length.
+func (token *JWT) SetSaltLength(saltLength int) {
+	if pss, ok := token.method.(*SigningMethodRSAPSS); ok {
+		method := *pss
+		method.SaltLength = saltLength
+		token.method = &method
+	}
+}
+
+// SetAllowedAlgorithms sets the list of "alg" header values accepted during validation.
+//
+// Only the algorithm of the JWT's signing method is allowed by default, and a
+// token is never verified with another method. If the JWT resolves keys with
+// a KeyFunc, the token is verified with the registered method of its algorithm
+// and nothing is allowed by default. Tokens whose algorithm isn't in the list
+// are rejected with ErrTokenAlgorithmNotAllowed before their signature is
+// checked, so a token can't pick an algorithm it isn't expected to use.
+func (token *JWT) SetAllowedAlgorithms(algs ...string) {
+	token.allowedAlgorithms = algs
+}
+
+// SetAudience sets the list of audiences the JWT accepts tokens for.
+//
+// If the list isn't empty, a token is valid only if its "aud" claim, either
+// a string or an array of strings, contains at least one of the audiences.
+func (token *JWT) SetAudience(aud ...string) {
+	token.audience = aud
+}
+
+// SetIssuer sets the list of trusted issuers.
+//
+// If the list isn't empty, a token is valid only if its "iss" claim is
+// exactly one of the issuers.
+func (token *JWT) SetIssuer(iss ...string) {
+	token.issuers = iss
+}
+
+// RequireSubject makes the "sub" claim required. If the pattern isn't nil,
+// the subject must also match it.
+func (token *JWT) RequireSubject(pattern *regexp.Regexp) {
+	token.requireSubject = true
+	token.subjectPattern = pattern
+}
+
+// RequireID makes the "jti" claim required.
+func (token *JWT) RequireID() {
+	token.requireID = true
+}
+
+// SetLeeway sets the clock skew tolerated when the exp, nbf and iat claims
+// are validated, usually no more than a few minutes (RFC 7519 §4.1.4).
+// A negative leeway is treated as zero.
+func (token *JWT) SetLeeway(leeway time.Duration) {
+	if leeway < 0 {
+		leeway = 0
+	}
+	token.leeway = leeway
+}
+
+// SetMaxAge sets the maximum age of a token.
+//
+// If the age is positive, a token is valid only if it has the "iat" claim and
+// was issued no more than the given duration ago, whatever its "exp" claim is.
+func (token *JWT) SetMaxAge(maxAge time.Duration) {
+	token.maxAge = maxAge
+}
+
+// SetReportAllErrors sets whether validation runs every claim check and
+// reports all failures in a single ValidationError instead of stopping at
+// the first one. The alg and signature checks always stop the validation,
+// so the claims of a token with an invalid signature are never checked.
+func (token *JWT) SetReportAllErrors(all bool) {
+	token.reportAllErrors = all
 }
 
-func (token *JWT) reset() {
-	token.signingHash.Reset()
+// SetCriticalHeaders sets the header parameters the application understands
+// and processes when they are listed in the "crit" header parameter.
+//
+// Tokens which list other parameters in "crit" are rejected as required by
+// RFC 7515 §4.1.11. The values of the parameters are available via Header.Get
+// once the token is parsed.
+func (token *JWT) SetCriticalHeaders(names ...string) {
+	token.criticalHeaders = names
+}
+
+// SetUseNumber makes decoding keep the numbers of the payload as json.Number
+// instead of float64, so integers beyond 2^53 don't lose precision. It affects
+// the values returned by Claims.Get and decoded into interface{} fields of
+// custom claims, the numeric getters accept both.
+func (token *JWT) SetUseNumber(use bool) {
+	token.useNumber = use
+}
+
+// SetClock sets the clock used to issue and validate tokens.
+//
+// It allows to validate tokens as of another time, e.g. to investigate
+// historical tokens, and makes time-dependent tests deterministic.
+func (token *JWT) SetClock(clock Clock) {
+	token.clock = clock
+}
+
+// getClock returns the JWT's clock.
+func (token *JWT) getClock() Clock {
+	if token.clock == nil {
+		return SystemClock
+	}
+	return token.clock
+}
+
+// now returns the current time of the JWT's clock.
+func (token *JWT) now() time.Time {
+	return token.getClock().Now()
 }
 
-func (token *JWT) write(data []byte) (int, error) {
-	return token.signingHash.Write(data)
+// NewClaims returns a new map representing the claims with "iat" claim value
+// taken from the JWT's clock.
+func (token *JWT) NewClaims() *Claims {
+	return NewClaimsWithClock(token.getClock())
 }
 
-// Sign signs the token with the given hash, and key
+// Sign signs the token with the signing method and key
 func (token *JWT) Sign(unsignedToken string) ([]byte, error) {
-	_, err := token.write([]byte(unsignedToken))
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("unable to write to %s", token.algorithm))
+	if token.method == nil {
+		return nil, ErrSigningMethodNotRegistered
 	}
-	encodedToken := token.sum(nil)
-	token.reset()
-	return encodedToken, nil
+	return token.method.Sign([]byte(unsignedToken), token.signingKey)
 }
 
 // Encode returns an encoded JWT token from a header, payload, and secret
 func (token *JWT) Encode(payload *Claims) (string, error) {
-	header := token.NewHeader()
+	return token.encode(token.NewHeader(), payload.claims)
+}
+
+// EncodeCustomClaims returns an encoded JWT token with the given claims as payload.
+// The claims are marshaled by encoding/json, so they are usually a struct which
+// embeds RegisteredClaims. *Claims are accepted as well.
+func (token *JWT) EncodeCustomClaims(claims interface{}) (string, error) {
+	return token.encode(token.NewHeader(), claims)
+}
+
+// EncodeWithHeader returns an encoded JWT token with the given header and claims,
+// which are either *Claims or custom claims as accepted by EncodeCustomClaims.
+//
+// The header is usually created by NewHeader and then amended, e.g. with a "kid".
+// Its "alg" is always set to the algorithm of the JWT's signing method.
+func (token *JWT) EncodeWithHeader(header *Header, claims interface{}) (string, error) {
+	h := *header
+	h.Alg = token.alg()
+	return token.encode(&h, claims)
+}
+
+// encode returns an encoded JWT token with the JSON representation of the header and payload.
+func (token *JWT) encode(header *Header, payload interface{}) (string, error) {
+	switch c := payload.(type) {
+	case *Claims:
+		payload = c.claims
+	case Claims:
+		payload = c.claims
+	}
 	jsonTokenHeader, err := json.Marshal(header)
 	if err != nil {
 		return "", ErrTokenUnableToMarshallHeader
 	}
 
 	b64TokenHeader := base64.RawURLEncoding.EncodeToString(jsonTokenHeader)
-	jsonTokenPayload, err := json.Marshal(payload.claims)
-	if err != nil {
+	jsonTokenPayload, err := json.Marshal(payload)
+	if err != nil || len(jsonTokenPayload) == 0 || jsonTokenPayload[0] != '{' {
+		// The claims set must be a JSON object (RFC 7519 §7.1).
 		return "", ErrTokenUnableToMarshallPayload
 	}
 
@@ -92,25 +295,112 @@ func (token *JWT) Encode(payload *Claims) (string, error) {
 
 // Decode returns a map representing the token's claims. DOESN'T validate the claims though.
 func (token *JWT) Decode(encoded string) (*Claims, error) {
+	parsed, err := token.decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Claims, nil
+}
+
+// decode splits the token and decodes its segments without validating them.
+func (token *JWT) decode(encoded string) (*Token, error) {
 	encryptedComponents := strings.Split(encoded, ".")
 	if len(encryptedComponents) != 3 {
 		return nil, ErrTokenIsMalformed
 	}
+	b64Header := encryptedComponents[0]
 	b64Payload := encryptedComponents[1]
+	b64Signature := encryptedComponents[2]
+
+	var header Header
+	jsonHeader, err := base64.RawURLEncoding.DecodeString(b64Header)
+	if err != nil {
+		return nil, ErrTokenUnableToDecodeB64Header
+	}
+	if err := json.Unmarshal(jsonHeader, &header); err != nil {
+		return nil, ErrTokenUnableToUnmarshallHeader
+	}
 
 	var claims map[string]interface{}
 	payload, err := base64.RawURLEncoding.DecodeString(b64Payload)
 	if err != nil {
 		return nil, ErrTokenUnableToDecodeB64Payload
 	}
-	if err := json.Unmarshal(payload, &claims); err != nil {
+	if err := unmarshalPayload(payload, &claims, token.useNumber); err != nil {
 		return nil, ErrTokenUnableToUnmarshallPayload
 	}
-	return &Claims{
-		claims: claims,
+
+	signature, err := base64.RawURLEncoding.DecodeString(b64Signature)
+	if err != nil {
+		return nil, ErrTokenUnableToDecodeB64Signature
+	}
+	return &Token{
+		Raw:      encoded,
+		Segments: [3]string{b64Header, b64Payload, b64Signature},
+		Header:   &header,
+		Claims: &Claims{
+			claims: claims,
+		},
+		Signature: signature,
 	}, nil
 }
 
+// DecodeCustomClaims unmarshals the token's payload into the given claims. DOESN'T validate the claims though.
+func (token *JWT) DecodeCustomClaims(encoded string, claims interface{}) error {
+	if _, err := token.decode(encoded); err != nil {
+		return err
+	}
+	return unmarshalCustomClaims(encoded, claims, token.useNumber)
+}
+
+// DecodeAndValidateCustomClaims unmarshals the token's payload into the given claims if the token is valid.
+//
+// The token is validated exactly as by DecodeAndValidate, so the registered
+// claims are checked whether or not the claims type has fields for them.
+func (token *JWT) DecodeAndValidateCustomClaims(encoded string, claims interface{}) error {
+	if _, err := token.DecodeAndValidate(encoded); err != nil {
+		return err
+	}
+	return unmarshalCustomClaims(encoded, claims, token.useNumber)
+}
+
+// unmarshalCustomClaims unmarshals the payload of a well-formed token into the given claims.
+func unmarshalCustomClaims(encoded string, claims interface{}, useNumber bool) error {
+	switch c := claims.(type) {
+	case *Claims:
+		claims = &c.claims
+	case **Claims:
+		if *c == nil {
+			*c = &Claims{}
+		}
+		claims = &(*c).claims
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(encoded, ".")[1])
+	if err != nil {
+		return ErrTokenUnableToDecodeB64Payload
+	}
+	if err := unmarshalPayload(payload, claims, useNumber); err != nil {
+		return ErrTokenUnableToUnmarshallPayload
+	}
+	return nil
+}
+
+// unmarshalPayload parses the JSON payload, keeping numbers as json.Number
+// if useNumber is set, and rejects any data after it.
+func unmarshalPayload(payload []byte, claims interface{}, useNumber bool) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	if useNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(claims); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return ErrTokenUnableToUnmarshallPayload
+	}
+	return nil
+}
+
 // Validate verifies a token's validity. It returns nil if it is valid, and an error if invalid.
 func (token *JWT) Validate(encoded string) error {
 	_, err := token.DecodeAndValidate(encoded)
@@ -118,45 +408,122 @@ func (token *JWT) Validate(encoded string) error {
 }
 
 // DecodeAndValidate returns a map representing the token's claims, and it's valid.
-func (token *JWT) DecodeAndValidate(encoded string) (claims *Claims, err error) {
-	claims, err = token.Decode(encoded)
+//
+// If the token is well-formed but invalid, the returned error is a *ValidationError.
+// A malformed token fails with one of the decoding errors, e.g. ErrTokenIsMalformed.
+func (token *JWT) DecodeAndValidate(encoded string) (*Claims, error) {
+	parsed, err := token.Parse(encoded)
+	if parsed == nil {
+		return nil, err
+	}
+	return parsed.Claims, err
+}
+
+// validate runs the checks of the decoded token.
+func (token *JWT) validate(parsed *Token) error {
+	method, err := token.validateAlgorithm(parsed.Header)
 	if err != nil {
-		return
+		return newValidationError(CheckAlgorithm, err)
 	}
-	if err = token.validateSignature(encoded); err != nil {
-		err = errors.New(fmt.Sprintf("failed to validate signature: %s", err.Error()))
-		return
+	if err := token.validateCritical(parsed.Header); err != nil {
+		return newValidationError(CheckCritical, err)
 	}
-	if err = token.validateExp(claims); err != nil {
-		err = errors.New(fmt.Sprintf("failed to validate exp: %s", err.Error()))
-		return
+	method, key, err := token.lookupKey(parsed, method)
+	if err != nil {
+		return newValidationError(CheckKey, err)
 	}
-	if err = token.validateNbf(claims); err != nil {
-		err = errors.New(fmt.Sprintf("failed to validate nbf: %s", err.Error()))
+	if err := method.Verify([]byte(parsed.signingInput()), parsed.Signature, key); err != nil {
+		return newValidationError(CheckSignature, err)
 	}
-	return
+	validationErr := &ValidationError{}
+	for _, c := range token.claimChecks() {
+		if err := c.validate(parsed.Claims); err != nil {
+			validationErr.add(c.check, err)
+			if !token.reportAllErrors {
+				break
+			}
+		}
+	}
+	if len(validationErr.Errors) > 0 {
+		return validationErr
+	}
+	return nil
 }
 
-// validateSignature verifies a token's signature.
-func (token *JWT) validateSignature(encoded string) error {
-	encryptedComponents := strings.Split(encoded, ".")
-
-	b64Header := encryptedComponents[0]
-	b64Payload := encryptedComponents[1]
-	b64Signature := encryptedComponents[2]
+// claimCheck is a check of the token's claims.
+type claimCheck struct {
+	check    ValidationCheck
+	validate func(claims *Claims) error
+}
 
-	unsignedAttempt := b64Header + "." + b64Payload
-	signedAttempt, err := token.Sign(unsignedAttempt)
-	if err != nil {
-		return ErrTokenUnableToSign
+// claimChecks returns the checks of the token's claims in the order they run.
+func (token *JWT) claimChecks() []claimCheck {
+	return []claimCheck{
+		{check: CheckExpiresAt, validate: token.validateExp},
+		{check: CheckNotBefore, validate: token.validateNbf},
+		{check: CheckIssuedAt, validate: token.validateIat},
+		{check: CheckAudience, validate: token.validateAud},
+		{check: CheckIssuer, validate: token.validateIss},
+		{check: CheckSubject, validate: token.validateSub},
+		{check: CheckID, validate: token.validateJti},
 	}
+}
 
-	b64SignedAttempt := base64.RawURLEncoding.EncodeToString([]byte(signedAttempt))
-
-	if !hmac.Equal([]byte(b64Signature), []byte(b64SignedAttempt)) {
-		return ErrTokenInvalidSignature
+// validateAlgorithm verifies a token's alg header against the allowed algorithms
+// and returns the signing method to verify the token with.
+func (token *JWT) validateAlgorithm(header *Header) (SigningMethod, error) {
+	if header.Alg == "" {
+		return nil, ErrTokenAlgorithmNotAllowed
+	}
+	allowed := token.allowedAlgorithms
+	if len(allowed) == 0 && token.keyFunc == nil && token.resolveKey == nil {
+		allowed = []string{token.alg()}
 	}
+	for _, alg := range allowed {
+		if alg != header.Alg {
+			continue
+		}
+		if token.resolveKey != nil {
+			// The method is resolved along with the key.
+			return nil, nil
+		}
+		if token.keyFunc != nil {
+			return GetSigningMethod(alg)
+		}
+		if token.method != nil && alg == token.alg() {
+			return token.method, nil
+		}
+	}
+	return nil, ErrTokenAlgorithmNotAllowed
+}
 
+// validateCritical verifies a token's crit header is well-formed
+// and lists only the supported header parameters.
+func (token *JWT) validateCritical(header *Header) error {
+	if header.Crit == nil {
+		return nil
+	}
+	if len(header.Crit) == 0 {
+		return ErrTokenInvalidCritical
+	}
+	for _, name := range header.Crit {
+		if registeredHeaderParameters[name] {
+			return ErrTokenInvalidCritical
+		}
+		if _, ok := header.Get(name); !ok {
+			return ErrTokenInvalidCritical
+		}
+		supported := false
+		for _, criticalHeader := range token.criticalHeaders {
+			if name == criticalHeader {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return ErrTokenUnsupportedCritical
+		}
+	}
 	return nil
 }
 
@@ -167,7 +534,7 @@ func (token *JWT) validateExp(claims *Claims) error {
 		if err != nil {
 			return err
 		}
-		if exp.Before(time.Now()) {
+		if exp.Add(token.leeway).Before(token.now()) {
 			return ErrTokenHasExpired
 		}
 	}
@@ -181,9 +548,111 @@ func (token *JWT) validateNbf(claims *Claims) error {
 		if err != nil {
 			return err
 		}
-		if nbf.After(time.Now()) {
+		if nbf.Add(-token.leeway).After(token.now()) {
 			return ErrTokenNotValid
 		}
 	}
 	return nil
 }
+
+// validateIat verifies a token's iat claim isn't in the future
+// and the token isn't older than the maximum age.
+func (token *JWT) validateIat(claims *Claims) error {
+	if !claims.Contains("iat") {
+		if token.maxAge > 0 {
+			return ErrTokenMissingIssuedAt
+		}
+		return nil
+	}
+	iat, err := claims.GetTime("iat")
+	if err != nil {
+		return err
+	}
+	now := token.now()
+	if iat.Add(-token.leeway).After(now) {
+		return ErrTokenUsedBeforeIssued
+	}
+	if token.maxAge > 0 && iat.Add(token.maxAge+token.leeway).Before(now) {
+		return ErrTokenTooOld
+	}
+	return nil
+}
+
+// validateAud verifies a token's aud claim.
+func (token *JWT) validateAud(claims *Claims) error {
+	if len(token.audience) == 0 {
+		return nil
+	}
+	if !claims.Contains("aud") {
+		return ErrTokenInvalidAudience
+	}
+	aud, err := claims.GetAudience("aud")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenInvalidAudience, err)
+	}
+	for _, expected := range token.audience {
+		if aud.Contains(expected) {
+			return nil
+		}
+	}
+	return ErrTokenInvalidAudience
+}
+
+// validateIss verifies a token's iss claim.
+func (token *JWT) validateIss(claims *Claims) error {
+	if len(token.issuers) == 0 {
+		return nil
+	}
+	if !claims.Contains("iss") {
+		return ErrTokenInvalidIssuer
+	}
+	iss, err := claims.GetString("iss")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenInvalidIssuer, err)
+	}
+	for _, trusted := range token.issuers {
+		if iss == trusted {
+			return nil
+		}
+	}
+	return ErrTokenInvalidIssuer
+}
+
+// validateSub verifies a token's sub claim.
+func (token *JWT) validateSub(claims *Claims) error {
+	if !token.requireSubject {
+		return nil
+	}
+	if !claims.Contains("sub") {
+		return ErrTokenMissingSubject
+	}
+	sub, err := claims.GetString("sub")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenInvalidSubject, err)
+	}
+	if sub == "" {
+		return ErrTokenMissingSubject
+	}
+	if token.subjectPattern != nil && !token.subjectPattern.MatchString(sub) {
+		return ErrTokenInvalidSubject
+	}
+	return nil
+}
+
+// validateJti verifies a token's jti claim.
+func (token *JWT) validateJti(claims *Claims) error {
+	if !token.requireID {
+		return nil
+	}
+	if !claims.Contains("jti") {
+		return ErrTokenMissingID
+	}
+	jti, err := claims.GetString("jti")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenInvalidID, err)
+	}
+	if jti == "" {
+		return ErrTokenMissingID
+	}
+	return nil
+}
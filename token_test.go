@@ -15,7 +15,17 @@
 
 package jwt
 
-import "testing"
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestJWT_NewHeader(t *testing.T) {
 	hs256 := HmacSha256("super-secret-key")
@@ -46,178 +56,350 @@ func TestJWT_NewHeader(t *testing.T) {
 	}
 }
 
-var TestJWT_sum_Data = []struct {
-	hs256 []struct{
-		data     []byte
-		expected []byte
+func TestJWT_ValidateAlgorithm(t *testing.T) {
+	key := rsaTestKey(t)
+	verifier := RsaSha256Verifier(&key.PublicKey)
+	var data = []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "none",
+			token: base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"none"}`)) + ".e30.",
+		},
+		{
+			name: "hmac with public key",
+			token: func() string {
+				publicKey := x509.MarshalPKCS1PublicKey(&key.PublicKey)
+				forger := New(SigningMethodHS256, publicKey, publicKey)
+				encoded, _ := forger.Encode(NewClaims())
+				return encoded
+			}(),
+		},
 	}
-	hs512 []struct{
-		data     []byte
-		expected []byte
+	for _, d := range data {
+		err := verifier.Validate(d.token)
+		if err == nil {
+			t.Errorf("jwt.TestJWT_ValidateAlgorithm, %s: token is valid", d.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), ErrTokenAlgorithmNotAllowed.Error()) {
+			t.Errorf("jwt.TestJWT_ValidateAlgorithm, %s: func returns an invalid error: %s", d.name, err)
+		}
 	}
-	hs384 []struct{
-		data     []byte
-		expected []byte
+}
+
+func TestJWT_ValidateWithoutMethod(t *testing.T) {
+	noAlg := "eyJ0eXAiOiJKV1QifQ.e30."
+	var data = []struct {
+		name  string
+		token JWT
+	}{
+		{name: "nil method", token: New(nil, nil, []byte("key"))},
+		{name: "zero JWT", token: JWT{}},
+		{name: "hs256", token: HmacSha256("super-secret-key")},
+	}
+	for _, d := range data {
+		if err := d.token.Validate(noAlg); !errors.Is(err, ErrTokenAlgorithmNotAllowed) {
+			t.Errorf("jwt.TestJWT_ValidateWithoutMethod, %s: %v is not %v", d.name, err, ErrTokenAlgorithmNotAllowed)
+		}
+	}
+}
+
+func TestJWT_SetAllowedAlgorithms(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	encoded, _ := hs256.Encode(NewClaims())
+	hs256.SetAllowedAlgorithms("HS384", "HS512")
+	if err := hs256.Validate(encoded); err == nil {
+		t.Errorf("jwt.TestJWT_SetAllowedAlgorithms: token with not allowed alg is valid")
+	}
+	hs256.SetAllowedAlgorithms("HS256", "HS512")
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_SetAllowedAlgorithms: %s", err)
 	}
-}{
-	{
-		hs256: []struct {
-			data     []byte
-			expected []byte
-		}{
-			{
-				data: []byte("Sodales molestie vel tempus. Dignissim egestas. Scelerisque nascetur bibendum ad morbi donec arcu orci. Tortor proin amet tortor mauris mi."),
-				expected: []byte{83, 111, 100, 97, 108, 101, 115, 32, 109, 111, 108, 101, 115, 116, 105, 101, 32, 118, 101, 108, 32, 116, 101, 109, 112, 117, 115, 46, 32, 68, 105, 103, 110, 105, 115, 115, 105, 109, 32, 101, 103, 101, 115, 116, 97, 115, 46, 32, 83, 99, 101, 108, 101, 114, 105, 115, 113, 117, 101, 32, 110, 97, 115, 99, 101, 116, 117, 114, 32, 98, 105, 98, 101, 110, 100, 117, 109, 32, 97, 100, 32, 109, 111, 114, 98, 105, 32, 100, 111, 110, 101, 99, 32, 97, 114, 99, 117, 32, 111, 114, 99, 105, 46, 32, 84, 111, 114, 116, 111, 114, 32, 112, 114, 111, 105, 110, 32, 97, 109, 101, 116, 32, 116, 111, 114, 116, 111, 114, 32, 109, 97, 117, 114, 105, 115, 32, 109, 105, 46, 79, 180, 82, 90, 118, 48, 186, 67, 67, 146, 59, 197, 159, 211, 138, 66, 250, 227, 69, 79, 1, 194, 24, 107, 53, 226, 18, 190, 137, 165, 188, 107},
-			},
-			{
-				data: []byte("Nisi sociis diam varius vulputate quam lacus viverra velit, ridiculus ac euismod curae; cubilia facilisis tellus dictum eleifend lorem ullamcorper."),
-				expected: []byte{78, 105, 115, 105, 32, 115, 111, 99, 105, 105, 115, 32, 100, 105, 97, 109, 32, 118, 97, 114, 105, 117, 115, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 113, 117, 97, 109, 32, 108, 97, 99, 117, 115, 32, 118, 105, 118, 101, 114, 114, 97, 32, 118, 101, 108, 105, 116, 44, 32, 114, 105, 100, 105, 99, 117, 108, 117, 115, 32, 97, 99, 32, 101, 117, 105, 115, 109, 111, 100, 32, 99, 117, 114, 97, 101, 59, 32, 99, 117, 98, 105, 108, 105, 97, 32, 102, 97, 99, 105, 108, 105, 115, 105, 115, 32, 116, 101, 108, 108, 117, 115, 32, 100, 105, 99, 116, 117, 109, 32, 101, 108, 101, 105, 102, 101, 110, 100, 32, 108, 111, 114, 101, 109, 32, 117, 108, 108, 97, 109, 99, 111, 114, 112, 101, 114, 46, 79, 180, 82, 90, 118, 48, 186, 67, 67, 146, 59, 197, 159, 211, 138, 66, 250, 227, 69, 79, 1, 194, 24, 107, 53, 226, 18, 190, 137, 165, 188, 107},
-			},
-			{
-				data: []byte("Volutpat condimentum per integer enim ac leo diam cras penatibus aliquam aenean odio. Faucibus. Ullamcorper aliquam. Litora praesent aliquet aptent."),
-				expected: []byte{86, 111, 108, 117, 116, 112, 97, 116, 32, 99, 111, 110, 100, 105, 109, 101, 110, 116, 117, 109, 32, 112, 101, 114, 32, 105, 110, 116, 101, 103, 101, 114, 32, 101, 110, 105, 109, 32, 97, 99, 32, 108, 101, 111, 32, 100, 105, 97, 109, 32, 99, 114, 97, 115, 32, 112, 101, 110, 97, 116, 105, 98, 117, 115, 32, 97, 108, 105, 113, 117, 97, 109, 32, 97, 101, 110, 101, 97, 110, 32, 111, 100, 105, 111, 46, 32, 70, 97, 117, 99, 105, 98, 117, 115, 46, 32, 85, 108, 108, 97, 109, 99, 111, 114, 112, 101, 114, 32, 97, 108, 105, 113, 117, 97, 109, 46, 32, 76, 105, 116, 111, 114, 97, 32, 112, 114, 97, 101, 115, 101, 110, 116, 32, 97, 108, 105, 113, 117, 101, 116, 32, 97, 112, 116, 101, 110, 116, 46, 79, 180, 82, 90, 118, 48, 186, 67, 67, 146, 59, 197, 159, 211, 138, 66, 250, 227, 69, 79, 1, 194, 24, 107, 53, 226, 18, 190, 137, 165, 188, 107},
-			},
-			{
-				data: []byte("Urna dis nisl vulputate aenean mauris, tempor cubilia ultrices pellentesque imperdiet Suscipit. Augue ridiculus sollicitudin nam, augue. Fames hendrerit natoque."),
-				expected: []byte{85, 114, 110, 97, 32, 100, 105, 115, 32, 110, 105, 115, 108, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 97, 101, 110, 101, 97, 110, 32, 109, 97, 117, 114, 105, 115, 44, 32, 116, 101, 109, 112, 111, 114, 32, 99, 117, 98, 105, 108, 105, 97, 32, 117, 108, 116, 114, 105, 99, 101, 115, 32, 112, 101, 108, 108, 101, 110, 116, 101, 115, 113, 117, 101, 32, 105, 109, 112, 101, 114, 100, 105, 101, 116, 32, 83, 117, 115, 99, 105, 112, 105, 116, 46, 32, 65, 117, 103, 117, 101, 32, 114, 105, 100, 105, 99, 117, 108, 117, 115, 32, 115, 111, 108, 108, 105, 99, 105, 116, 117, 100, 105, 110, 32, 110, 97, 109, 44, 32, 97, 117, 103, 117, 101, 46, 32, 70, 97, 109, 101, 115, 32, 104, 101, 110, 100, 114, 101, 114, 105, 116, 32, 110, 97, 116, 111, 113, 117, 101, 46, 79, 180, 82, 90, 118, 48, 186, 67, 67, 146, 59, 197, 159, 211, 138, 66, 250, 227, 69, 79, 1, 194, 24, 107, 53, 226, 18, 190, 137, 165, 188, 107},
-			},
-			{
-				data: []byte("Natoque porttitor sapien vulputate dolor mattis elementum iaculis inceptos in. Consectetuer class urna non egestas. Pellentesque posuere senectus iaculis hymenaeos."),
-				expected: []byte{78, 97, 116, 111, 113, 117, 101, 32, 112, 111, 114, 116, 116, 105, 116, 111, 114, 32, 115, 97, 112, 105, 101, 110, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 100, 111, 108, 111, 114, 32, 109, 97, 116, 116, 105, 115, 32, 101, 108, 101, 109, 101, 110, 116, 117, 109, 32, 105, 97, 99, 117, 108, 105, 115, 32, 105, 110, 99, 101, 112, 116, 111, 115, 32, 105, 110, 46, 32, 67, 111, 110, 115, 101, 99, 116, 101, 116, 117, 101, 114, 32, 99, 108, 97, 115, 115, 32, 117, 114, 110, 97, 32, 110, 111, 110, 32, 101, 103, 101, 115, 116, 97, 115, 46, 32, 80, 101, 108, 108, 101, 110, 116, 101, 115, 113, 117, 101, 32, 112, 111, 115, 117, 101, 114, 101, 32, 115, 101, 110, 101, 99, 116, 117, 115, 32, 105, 97, 99, 117, 108, 105, 115, 32, 104, 121, 109, 101, 110, 97, 101, 111, 115, 46, 79, 180, 82, 90, 118, 48, 186, 67, 67, 146, 59, 197, 159, 211, 138, 66, 250, 227, 69, 79, 1, 194, 24, 107, 53, 226, 18, 190, 137, 165, 188, 107},
-			},
-		},
-		hs512: []struct {
-			data     []byte
-			expected []byte
-		}{
-			{
-				data: []byte("Sodales molestie vel tempus. Dignissim egestas. Scelerisque nascetur bibendum ad morbi donec arcu orci. Tortor proin amet tortor mauris mi."),
-				expected: []byte{83, 111, 100, 97, 108, 101, 115, 32, 109, 111, 108, 101, 115, 116, 105, 101, 32, 118, 101, 108, 32, 116, 101, 109, 112, 117, 115, 46, 32, 68, 105, 103, 110, 105, 115, 115, 105, 109, 32, 101, 103, 101, 115, 116, 97, 115, 46, 32, 83, 99, 101, 108, 101, 114, 105, 115, 113, 117, 101, 32, 110, 97, 115, 99, 101, 116, 117, 114, 32, 98, 105, 98, 101, 110, 100, 117, 109, 32, 97, 100, 32, 109, 111, 114, 98, 105, 32, 100, 111, 110, 101, 99, 32, 97, 114, 99, 117, 32, 111, 114, 99, 105, 46, 32, 84, 111, 114, 116, 111, 114, 32, 112, 114, 111, 105, 110, 32, 97, 109, 101, 116, 32, 116, 111, 114, 116, 111, 114, 32, 109, 97, 117, 114, 105, 115, 32, 109, 105, 46, 174, 224, 4, 207, 124, 104, 164, 198, 20, 76, 106, 94, 17, 194, 163, 38, 1, 183, 25, 224, 174, 183, 58, 204, 156, 70, 211, 154, 144, 102, 192, 89, 0, 11, 7, 46, 49, 225, 50, 156, 59, 228, 83, 190, 153, 171, 195, 198, 208, 183, 146, 225, 186, 125, 49, 11, 252, 87, 198, 113, 252, 108, 78, 176},
-			},
-			{
-				data: []byte("Nisi sociis diam varius vulputate quam lacus viverra velit, ridiculus ac euismod curae; cubilia facilisis tellus dictum eleifend lorem ullamcorper."),
-				expected: []byte{78, 105, 115, 105, 32, 115, 111, 99, 105, 105, 115, 32, 100, 105, 97, 109, 32, 118, 97, 114, 105, 117, 115, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 113, 117, 97, 109, 32, 108, 97, 99, 117, 115, 32, 118, 105, 118, 101, 114, 114, 97, 32, 118, 101, 108, 105, 116, 44, 32, 114, 105, 100, 105, 99, 117, 108, 117, 115, 32, 97, 99, 32, 101, 117, 105, 115, 109, 111, 100, 32, 99, 117, 114, 97, 101, 59, 32, 99, 117, 98, 105, 108, 105, 97, 32, 102, 97, 99, 105, 108, 105, 115, 105, 115, 32, 116, 101, 108, 108, 117, 115, 32, 100, 105, 99, 116, 117, 109, 32, 101, 108, 101, 105, 102, 101, 110, 100, 32, 108, 111, 114, 101, 109, 32, 117, 108, 108, 97, 109, 99, 111, 114, 112, 101, 114, 46, 174, 224, 4, 207, 124, 104, 164, 198, 20, 76, 106, 94, 17, 194, 163, 38, 1, 183, 25, 224, 174, 183, 58, 204, 156, 70, 211, 154, 144, 102, 192, 89, 0, 11, 7, 46, 49, 225, 50, 156, 59, 228, 83, 190, 153, 171, 195, 198, 208, 183, 146, 225, 186, 125, 49, 11, 252, 87, 198, 113, 252, 108, 78, 176},
-			},
-			{
-				data: []byte("Volutpat condimentum per integer enim ac leo diam cras penatibus aliquam aenean odio. Faucibus. Ullamcorper aliquam. Litora praesent aliquet aptent."),
-				expected: []byte{86, 111, 108, 117, 116, 112, 97, 116, 32, 99, 111, 110, 100, 105, 109, 101, 110, 116, 117, 109, 32, 112, 101, 114, 32, 105, 110, 116, 101, 103, 101, 114, 32, 101, 110, 105, 109, 32, 97, 99, 32, 108, 101, 111, 32, 100, 105, 97, 109, 32, 99, 114, 97, 115, 32, 112, 101, 110, 97, 116, 105, 98, 117, 115, 32, 97, 108, 105, 113, 117, 97, 109, 32, 97, 101, 110, 101, 97, 110, 32, 111, 100, 105, 111, 46, 32, 70, 97, 117, 99, 105, 98, 117, 115, 46, 32, 85, 108, 108, 97, 109, 99, 111, 114, 112, 101, 114, 32, 97, 108, 105, 113, 117, 97, 109, 46, 32, 76, 105, 116, 111, 114, 97, 32, 112, 114, 97, 101, 115, 101, 110, 116, 32, 97, 108, 105, 113, 117, 101, 116, 32, 97, 112, 116, 101, 110, 116, 46, 174, 224, 4, 207, 124, 104, 164, 198, 20, 76, 106, 94, 17, 194, 163, 38, 1, 183, 25, 224, 174, 183, 58, 204, 156, 70, 211, 154, 144, 102, 192, 89, 0, 11, 7, 46, 49, 225, 50, 156, 59, 228, 83, 190, 153, 171, 195, 198, 208, 183, 146, 225, 186, 125, 49, 11, 252, 87, 198, 113, 252, 108, 78, 176},
-			},
-			{
-				data: []byte("Urna dis nisl vulputate aenean mauris, tempor cubilia ultrices pellentesque imperdiet Suscipit. Augue ridiculus sollicitudin nam, augue. Fames hendrerit natoque."),
-				expected: []byte{85, 114, 110, 97, 32, 100, 105, 115, 32, 110, 105, 115, 108, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 97, 101, 110, 101, 97, 110, 32, 109, 97, 117, 114, 105, 115, 44, 32, 116, 101, 109, 112, 111, 114, 32, 99, 117, 98, 105, 108, 105, 97, 32, 117, 108, 116, 114, 105, 99, 101, 115, 32, 112, 101, 108, 108, 101, 110, 116, 101, 115, 113, 117, 101, 32, 105, 109, 112, 101, 114, 100, 105, 101, 116, 32, 83, 117, 115, 99, 105, 112, 105, 116, 46, 32, 65, 117, 103, 117, 101, 32, 114, 105, 100, 105, 99, 117, 108, 117, 115, 32, 115, 111, 108, 108, 105, 99, 105, 116, 117, 100, 105, 110, 32, 110, 97, 109, 44, 32, 97, 117, 103, 117, 101, 46, 32, 70, 97, 109, 101, 115, 32, 104, 101, 110, 100, 114, 101, 114, 105, 116, 32, 110, 97, 116, 111, 113, 117, 101, 46, 174, 224, 4, 207, 124, 104, 164, 198, 20, 76, 106, 94, 17, 194, 163, 38, 1, 183, 25, 224, 174, 183, 58, 204, 156, 70, 211, 154, 144, 102, 192, 89, 0, 11, 7, 46, 49, 225, 50, 156, 59, 228, 83, 190, 153, 171, 195, 198, 208, 183, 146, 225, 186, 125, 49, 11, 252, 87, 198, 113, 252, 108, 78, 176},
-			},
-			{
-				data: []byte("Natoque porttitor sapien vulputate dolor mattis elementum iaculis inceptos in. Consectetuer class urna non egestas. Pellentesque posuere senectus iaculis hymenaeos."),
-				expected: []byte{78, 97, 116, 111, 113, 117, 101, 32, 112, 111, 114, 116, 116, 105, 116, 111, 114, 32, 115, 97, 112, 105, 101, 110, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 100, 111, 108, 111, 114, 32, 109, 97, 116, 116, 105, 115, 32, 101, 108, 101, 109, 101, 110, 116, 117, 109, 32, 105, 97, 99, 117, 108, 105, 115, 32, 105, 110, 99, 101, 112, 116, 111, 115, 32, 105, 110, 46, 32, 67, 111, 110, 115, 101, 99, 116, 101, 116, 117, 101, 114, 32, 99, 108, 97, 115, 115, 32, 117, 114, 110, 97, 32, 110, 111, 110, 32, 101, 103, 101, 115, 116, 97, 115, 46, 32, 80, 101, 108, 108, 101, 110, 116, 101, 115, 113, 117, 101, 32, 112, 111, 115, 117, 101, 114, 101, 32, 115, 101, 110, 101, 99, 116, 117, 115, 32, 105, 97, 99, 117, 108, 105, 115, 32, 104, 121, 109, 101, 110, 97, 101, 111, 115, 46, 174, 224, 4, 207, 124, 104, 164, 198, 20, 76, 106, 94, 17, 194, 163, 38, 1, 183, 25, 224, 174, 183, 58, 204, 156, 70, 211, 154, 144, 102, 192, 89, 0, 11, 7, 46, 49, 225, 50, 156, 59, 228, 83, 190, 153, 171, 195, 198, 208, 183, 146, 225, 186, 125, 49, 11, 252, 87, 198, 113, 252, 108, 78, 176},
-			},
-		},
-		hs384: []struct {
-			data     []byte
-			expected []byte
-		}{
-			{
-				data: []byte("Sodales molestie vel tempus. Dignissim egestas. Scelerisque nascetur bibendum ad morbi donec arcu orci. Tortor proin amet tortor mauris mi."),
-				expected: []byte{83, 111, 100, 97, 108, 101, 115, 32, 109, 111, 108, 101, 115, 116, 105, 101, 32, 118, 101, 108, 32, 116, 101, 109, 112, 117, 115, 46, 32, 68, 105, 103, 110, 105, 115, 115, 105, 109, 32, 101, 103, 101, 115, 116, 97, 115, 46, 32, 83, 99, 101, 108, 101, 114, 105, 115, 113, 117, 101, 32, 110, 97, 115, 99, 101, 116, 117, 114, 32, 98, 105, 98, 101, 110, 100, 117, 109, 32, 97, 100, 32, 109, 111, 114, 98, 105, 32, 100, 111, 110, 101, 99, 32, 97, 114, 99, 117, 32, 111, 114, 99, 105, 46, 32, 84, 111, 114, 116, 111, 114, 32, 112, 114, 111, 105, 110, 32, 97, 109, 101, 116, 32, 116, 111, 114, 116, 111, 114, 32, 109, 97, 117, 114, 105, 115, 32, 109, 105, 46, 255, 14, 230, 151, 208, 226, 125, 56, 127, 255, 150, 158, 59, 131, 134, 198, 30, 255, 123, 181, 170, 184, 13, 120, 134, 13, 66, 254, 70, 106, 127, 40, 199, 178, 8, 105, 81, 2, 47, 157, 72, 187, 235, 172, 123, 119, 251, 46},
-			},
-			{
-				data: []byte("Nisi sociis diam varius vulputate quam lacus viverra velit, ridiculus ac euismod curae; cubilia facilisis tellus dictum eleifend lorem ullamcorper."),
-				expected: []byte{78, 105, 115, 105, 32, 115, 111, 99, 105, 105, 115, 32, 100, 105, 97, 109, 32, 118, 97, 114, 105, 117, 115, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 113, 117, 97, 109, 32, 108, 97, 99, 117, 115, 32, 118, 105, 118, 101, 114, 114, 97, 32, 118, 101, 108, 105, 116, 44, 32, 114, 105, 100, 105, 99, 117, 108, 117, 115, 32, 97, 99, 32, 101, 117, 105, 115, 109, 111, 100, 32, 99, 117, 114, 97, 101, 59, 32, 99, 117, 98, 105, 108, 105, 97, 32, 102, 97, 99, 105, 108, 105, 115, 105, 115, 32, 116, 101, 108, 108, 117, 115, 32, 100, 105, 99, 116, 117, 109, 32, 101, 108, 101, 105, 102, 101, 110, 100, 32, 108, 111, 114, 101, 109, 32, 117, 108, 108, 97, 109, 99, 111, 114, 112, 101, 114, 46, 255, 14, 230, 151, 208, 226, 125, 56, 127, 255, 150, 158, 59, 131, 134, 198, 30, 255, 123, 181, 170, 184, 13, 120, 134, 13, 66, 254, 70, 106, 127, 40, 199, 178, 8, 105, 81, 2, 47, 157, 72, 187, 235, 172, 123, 119, 251, 46},
-			},
-			{
-				data: []byte("Volutpat condimentum per integer enim ac leo diam cras penatibus aliquam aenean odio. Faucibus. Ullamcorper aliquam. Litora praesent aliquet aptent."),
-				expected: []byte{86, 111, 108, 117, 116, 112, 97, 116, 32, 99, 111, 110, 100, 105, 109, 101, 110, 116, 117, 109, 32, 112, 101, 114, 32, 105, 110, 116, 101, 103, 101, 114, 32, 101, 110, 105, 109, 32, 97, 99, 32, 108, 101, 111, 32, 100, 105, 97, 109, 32, 99, 114, 97, 115, 32, 112, 101, 110, 97, 116, 105, 98, 117, 115, 32, 97, 108, 105, 113, 117, 97, 109, 32, 97, 101, 110, 101, 97, 110, 32, 111, 100, 105, 111, 46, 32, 70, 97, 117, 99, 105, 98, 117, 115, 46, 32, 85, 108, 108, 97, 109, 99, 111, 114, 112, 101, 114, 32, 97, 108, 105, 113, 117, 97, 109, 46, 32, 76, 105, 116, 111, 114, 97, 32, 112, 114, 97, 101, 115, 101, 110, 116, 32, 97, 108, 105, 113, 117, 101, 116, 32, 97, 112, 116, 101, 110, 116, 46, 255, 14, 230, 151, 208, 226, 125, 56, 127, 255, 150, 158, 59, 131, 134, 198, 30, 255, 123, 181, 170, 184, 13, 120, 134, 13, 66, 254, 70, 106, 127, 40, 199, 178, 8, 105, 81, 2, 47, 157, 72, 187, 235, 172, 123, 119, 251, 46},
-			},
-			{
-				data: []byte("Urna dis nisl vulputate aenean mauris, tempor cubilia ultrices pellentesque imperdiet Suscipit. Augue ridiculus sollicitudin nam, augue. Fames hendrerit natoque."),
-				expected: []byte{85, 114, 110, 97, 32, 100, 105, 115, 32, 110, 105, 115, 108, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 97, 101, 110, 101, 97, 110, 32, 109, 97, 117, 114, 105, 115, 44, 32, 116, 101, 109, 112, 111, 114, 32, 99, 117, 98, 105, 108, 105, 97, 32, 117, 108, 116, 114, 105, 99, 101, 115, 32, 112, 101, 108, 108, 101, 110, 116, 101, 115, 113, 117, 101, 32, 105, 109, 112, 101, 114, 100, 105, 101, 116, 32, 83, 117, 115, 99, 105, 112, 105, 116, 46, 32, 65, 117, 103, 117, 101, 32, 114, 105, 100, 105, 99, 117, 108, 117, 115, 32, 115, 111, 108, 108, 105, 99, 105, 116, 117, 100, 105, 110, 32, 110, 97, 109, 44, 32, 97, 117, 103, 117, 101, 46, 32, 70, 97, 109, 101, 115, 32, 104, 101, 110, 100, 114, 101, 114, 105, 116, 32, 110, 97, 116, 111, 113, 117, 101, 46, 255, 14, 230, 151, 208, 226, 125, 56, 127, 255, 150, 158, 59, 131, 134, 198, 30, 255, 123, 181, 170, 184, 13, 120, 134, 13, 66, 254, 70, 106, 127, 40, 199, 178, 8, 105, 81, 2, 47, 157, 72, 187, 235, 172, 123, 119, 251, 46},
-			},
-			{
-				data: []byte("Natoque porttitor sapien vulputate dolor mattis elementum iaculis inceptos in. Consectetuer class urna non egestas. Pellentesque posuere senectus iaculis hymenaeos."),
-				expected: []byte{78, 97, 116, 111, 113, 117, 101, 32, 112, 111, 114, 116, 116, 105, 116, 111, 114, 32, 115, 97, 112, 105, 101, 110, 32, 118, 117, 108, 112, 117, 116, 97, 116, 101, 32, 100, 111, 108, 111, 114, 32, 109, 97, 116, 116, 105, 115, 32, 101, 108, 101, 109, 101, 110, 116, 117, 109, 32, 105, 97, 99, 117, 108, 105, 115, 32, 105, 110, 99, 101, 112, 116, 111, 115, 32, 105, 110, 46, 32, 67, 111, 110, 115, 101, 99, 116, 101, 116, 117, 101, 114, 32, 99, 108, 97, 115, 115, 32, 117, 114, 110, 97, 32, 110, 111, 110, 32, 101, 103, 101, 115, 116, 97, 115, 46, 32, 80, 101, 108, 108, 101, 110, 116, 101, 115, 113, 117, 101, 32, 112, 111, 115, 117, 101, 114, 101, 32, 115, 101, 110, 101, 99, 116, 117, 115, 32, 105, 97, 99, 117, 108, 105, 115, 32, 104, 121, 109, 101, 110, 97, 101, 111, 115, 46, 255, 14, 230, 151, 208, 226, 125, 56, 127, 255, 150, 158, 59, 131, 134, 198, 30, 255, 123, 181, 170, 184, 13, 120, 134, 13, 66, 254, 70, 106, 127, 40, 199, 178, 8, 105, 81, 2, 47, 157, 72, 187, 235, 172, 123, 119, 251, 46},
-			},
-		},
-	},
 }
 
-func TestJWT_sum(t *testing.T) {
+func TestJWT_DecodeMalformedHeader(t *testing.T) {
 	hs256 := HmacSha256("super-secret-key")
-	for _, data := range TestJWT_sum_Data {
-		for _, d := range data.hs256 {
-			actual := hs256.sum(d.data)
-			if len(actual) != len(d.expected) {
-				t.Errorf("jwt.TestJWT_sum, hs256: invalid sum len: %d != %d", len(actual), len(d.expected))
-			}
-			for i, b := range actual {
-				if b != d.expected[i] {
-					t.Errorf("jwt.TestJWT_sum, hs256: invalid sum[%d]: %d != %d", i, b, d.expected[i])
+	if _, err := hs256.Decode("not-base64!.e30.sig"); err != ErrTokenUnableToDecodeB64Header {
+		t.Errorf("jwt.TestJWT_DecodeMalformedHeader: %v != %v", err, ErrTokenUnableToDecodeB64Header)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte("{"))
+	if _, err := hs256.Decode(header + ".e30.sig"); err != ErrTokenUnableToUnmarshallHeader {
+		t.Errorf("jwt.TestJWT_DecodeMalformedHeader: %v != %v", err, ErrTokenUnableToUnmarshallHeader)
+	}
+}
+
+func TestJWT_Concurrency(t *testing.T) {
+	key := rsaTestKey(t)
+	var data = []struct {
+		name  string
+		token JWT
+	}{
+		{name: "hs256", token: HmacSha256("super-secret-key")},
+		{name: "rs256", token: RsaSha256(key)},
+	}
+	for _, d := range data {
+		token := d.token
+		var wg sync.WaitGroup
+		errs := make(chan error, 16*25)
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 25; j++ {
+					claims := NewClaims()
+					claims.Set("sub", fmt.Sprintf("user-%d-%d", i, j))
+					encoded, err := token.Encode(claims)
+					if err != nil {
+						errs <- err
+						continue
+					}
+					decoded, err := token.DecodeAndValidate(encoded)
+					if err != nil {
+						errs <- err
+						continue
+					}
+					if sub, _ := decoded.GetString("sub"); sub != fmt.Sprintf("user-%d-%d", i, j) {
+						errs <- fmt.Errorf("invalid sub: %s", sub)
+					}
 				}
-			}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("jwt.TestJWT_Concurrency, %s: %s", d.name, err)
 		}
 	}
+}
 
-	hs512 := HmacSha512("super-secret-key")
-	for _, data := range TestJWT_sum_Data {
-		for _, d := range data.hs512 {
-			actual := hs512.sum(d.data)
-			if len(actual) != len(d.expected) {
-				t.Errorf("jwt.TestJWT_sum, hs512: invalid sum len: %d != %d", len(actual), len(d.expected))
-			}
-			for i, b := range actual {
-				if b != d.expected[i] {
-					t.Errorf("jwt.TestJWT_sum, hs512: invalid sum[%d]: %d != %d", i, b, d.expected[i])
-				}
-			}
+func TestJWT_ValidateExp(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	claims := NewClaims()
+	claims.SetTime("exp", time.Now().Add(time.Minute))
+	encoded, _ := hs256.Encode(claims)
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_ValidateExp: %s", err)
+	}
+	claims.SetTime("exp", time.Now().Add(-time.Minute))
+	encoded, _ = hs256.Encode(claims)
+	err := hs256.Validate(encoded)
+	if err == nil || !strings.Contains(err.Error(), ErrTokenHasExpired.Error()) {
+		t.Errorf("jwt.TestJWT_ValidateExp: expired token: %v", err)
+	}
+}
+
+var TestJWT_ValidateAud_Data = []struct {
+	aud   interface{}
+	valid bool
+}{
+	{aud: "api", valid: true},
+	{aud: []string{"web", "api"}, valid: true},
+	{aud: "web", valid: false},
+	{aud: []string{"web", "mobile"}, valid: false},
+	{aud: []interface{}{"api", 42}, valid: false},
+	{aud: 42, valid: false},
+	{aud: nil, valid: false},
+}
+
+func TestJWT_ValidateAud(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	hs256.SetAudience("api", "admin")
+	for _, data := range TestJWT_ValidateAud_Data {
+		claims := NewClaims()
+		if data.aud != nil {
+			claims.Set("aud", data.aud)
+		}
+		encoded, _ := hs256.Encode(claims)
+		err := hs256.Validate(encoded)
+		if data.valid && err != nil {
+			t.Errorf("jwt.TestJWT_ValidateAud, %v: %s", data.aud, err)
+		}
+		if !data.valid && !errors.Is(err, ErrTokenInvalidAudience) {
+			t.Errorf("jwt.TestJWT_ValidateAud, %v: %v is not %v", data.aud, err, ErrTokenInvalidAudience)
 		}
 	}
+	hs256.SetAudience()
+	encoded, _ := hs256.Encode(NewClaims())
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_ValidateAud: %s", err)
+	}
+}
 
-	hs384 := HmacSha384("super-secret-key")
-	for _, data := range TestJWT_sum_Data {
-		for _, d := range data.hs384 {
-			actual := hs384.sum(d.data)
-			if len(actual) != len(d.expected) {
-				t.Errorf("jwt.TestJWT_sum, hs384: invalid sum len: %d != %d", len(actual), len(d.expected))
-			}
-			for i, b := range actual {
-				if b != d.expected[i] {
-					t.Errorf("jwt.TestJWT_sum, hs384: invalid sum[%d]: %d != %d", i, b, d.expected[i])
-				}
-			}
+func TestJWT_ValidateIss(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	hs256.SetIssuer("https://auth.example.com", "https://sso.example.com")
+	var data = []struct {
+		iss interface{}
+		err error
+	}{
+		{iss: "https://sso.example.com", err: nil},
+		{iss: "https://auth.example.com/", err: ErrTokenInvalidIssuer},
+		{iss: nil, err: ErrTokenInvalidIssuer},
+		{iss: 42, err: ErrTokenInvalidIssuer},
+	}
+	for _, d := range data {
+		claims := NewClaims()
+		if d.iss != nil {
+			claims.Set("iss", d.iss)
+		}
+		encoded, _ := hs256.Encode(claims)
+		err := hs256.Validate(encoded)
+		if d.err == nil && err != nil {
+			t.Errorf("jwt.TestJWT_ValidateIss, %v: %s", d.iss, err)
+		}
+		if d.err != nil && !errors.Is(err, d.err) {
+			t.Errorf("jwt.TestJWT_ValidateIss, %v: %v is not %v", d.iss, err, d.err)
+		}
+	}
+}
+
+func TestJWT_ValidateSub(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	hs256.RequireSubject(regexp.MustCompile(`^user-[0-9]+$`))
+	var data = []struct {
+		sub interface{}
+		err error
+	}{
+		{sub: "user-42", err: nil},
+		{sub: "admin", err: ErrTokenInvalidSubject},
+		{sub: "", err: ErrTokenMissingSubject},
+		{sub: nil, err: ErrTokenMissingSubject},
+		{sub: 42, err: ErrTokenInvalidSubject},
+	}
+	for _, d := range data {
+		claims := NewClaims()
+		if d.sub != nil {
+			claims.Set("sub", d.sub)
+		}
+		encoded, _ := hs256.Encode(claims)
+		err := hs256.Validate(encoded)
+		if d.err == nil && err != nil {
+			t.Errorf("jwt.TestJWT_ValidateSub, %v: %s", d.sub, err)
+		}
+		if d.err != nil && !errors.Is(err, d.err) {
+			t.Errorf("jwt.TestJWT_ValidateSub, %v: %v is not %v", d.sub, err, d.err)
 		}
 	}
 }
 
-var TestJWT_write_Data = []struct {
-	data     []byte
-	expected int
+func TestJWT_ValidateJti(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	encoded, _ := hs256.Encode(NewClaims())
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_ValidateJti: %s", err)
+	}
+	hs256.RequireID()
+	err := hs256.Validate(encoded)
+	if err == nil || !strings.Contains(err.Error(), ErrTokenMissingID.Error()) {
+		t.Errorf("jwt.TestJWT_ValidateJti: %v doesn't contain %v", err, ErrTokenMissingID)
+	}
+	claims := NewClaims()
+	claims.Set("jti", "6f1c9b2e")
+	encoded, _ = hs256.Encode(claims)
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_ValidateJti: %s", err)
+	}
+	claims.Set("jti", 42)
+	encoded, _ = hs256.Encode(claims)
+	if err := hs256.Validate(encoded); !errors.Is(err, ErrTokenInvalidID) {
+		t.Errorf("jwt.TestJWT_ValidateJti: %v is not %v", err, ErrTokenInvalidID)
+	}
+}
+
+var TestJWT_SetLeeway_Data = []struct {
+	claim  string
+	offset time.Duration
+	err    error
 }{
-	{
-		data: []byte("Sodales molestie vel tempus. Dignissim egestas. Scelerisque nascetur bibendum ad morbi donec arcu orci. Tortor proin amet tortor mauris mi."),
-		expected: 139,
-	},
-	{
-		data: []byte("Nisi sociis diam varius vulputate quam lacus viverra velit, ridiculus ac euismod curae; cubilia facilisis tellus dictum eleifend lorem ullamcorper."),
-		expected: 147,
-	},
-	{
-		data: []byte("Volutpat condimentum per integer enim ac leo diam cras penatibus aliquam aenean odio. Faucibus. Ullamcorper aliquam. Litora praesent aliquet aptent."),
-		expected: 148,
-	},
-	{
-		data: []byte("Urna dis nisl vulputate aenean mauris, tempor cubilia ultrices pellentesque imperdiet Suscipit. Augue ridiculus sollicitudin nam, augue. Fames hendrerit natoque."),
-		expected: 161,
-	},
-	{
-		data: []byte("Natoque porttitor sapien vulputate dolor mattis elementum iaculis inceptos in. Consectetuer class urna non egestas. Pellentesque posuere senectus iaculis hymenaeos."),
-		expected: 164,
-	},
-}
-
-func TestJWT_write(t *testing.T) {
+	{claim: "exp", offset: -20 * time.Second, err: ErrTokenHasExpired},
+	{claim: "nbf", offset: 20 * time.Second, err: ErrTokenNotValid},
+	{claim: "iat", offset: 20 * time.Second, err: ErrTokenUsedBeforeIssued},
+}
+
+func TestJWT_SetLeeway(t *testing.T) {
 	hs256 := HmacSha256("super-secret-key")
-	for _, data := range TestJWT_write_Data {
-		actual, _ := hs256.write(data.data)
-		if actual != data.expected {
-			t.Errorf("jwt.TestJWT_write: invalid write result: %d != %d", actual, data.expected)
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	hs256.SetClock(clock)
+	for _, data := range TestJWT_SetLeeway_Data {
+		claims := hs256.NewClaims()
+		claims.SetTime(data.claim, clock.Now().Add(data.offset))
+		encoded, _ := hs256.Encode(claims)
+
+		hs256.SetLeeway(0)
+		err := hs256.Validate(encoded)
+		if err == nil || !strings.Contains(err.Error(), data.err.Error()) {
+			t.Errorf("jwt.TestJWT_SetLeeway, %s: %v doesn't contain %v", data.claim, err, data.err)
 		}
+		hs256.SetLeeway(time.Minute)
+		if err := hs256.Validate(encoded); err != nil {
+			t.Errorf("jwt.TestJWT_SetLeeway, %s: %s", data.claim, err)
+		}
+	}
+}
+
+func TestJWT_SetNegativeLeeway(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	hs256.SetLeeway(-time.Hour)
+	claims := hs256.NewClaims()
+	claims.SetTime("exp", time.Now().Add(time.Minute))
+	encoded, _ := hs256.Encode(claims)
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_SetNegativeLeeway: %s", err)
+	}
+}
+
+func TestJWT_SetClock(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	clock := NewFakeClock(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+	hs256.SetClock(clock)
+	claims := hs256.NewClaims()
+	if iat, _ := claims.GetTime("iat"); !iat.Equal(clock.Now()) {
+		t.Errorf("jwt.TestJWT_SetClock: invalid iat: %s != %s", iat, clock.Now())
+	}
+	claims.SetTime("nbf", clock.Now().Add(time.Hour))
+	claims.SetTime("exp", clock.Now().Add(2*time.Hour))
+	encoded, _ := hs256.Encode(claims)
+
+	err := hs256.Validate(encoded)
+	if err == nil || !strings.Contains(err.Error(), ErrTokenNotValid.Error()) {
+		t.Errorf("jwt.TestJWT_SetClock: %v doesn't contain %v", err, ErrTokenNotValid)
+	}
+	clock.Advance(90 * time.Minute)
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_SetClock: %s", err)
+	}
+	clock.Advance(time.Hour)
+	err = hs256.Validate(encoded)
+	if err == nil || !strings.Contains(err.Error(), ErrTokenHasExpired.Error()) {
+		t.Errorf("jwt.TestJWT_SetClock: %v doesn't contain %v", err, ErrTokenHasExpired)
+	}
+}
+
+func TestJWT_SetMaxAge(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	hs256.SetClock(clock)
+	hs256.SetMaxAge(10 * time.Minute)
+	claims := hs256.NewClaims()
+	claims.SetTime("exp", clock.Now().Add(24*time.Hour))
+	encoded, _ := hs256.Encode(claims)
+
+	clock.Advance(5 * time.Minute)
+	if err := hs256.Validate(encoded); err != nil {
+		t.Errorf("jwt.TestJWT_SetMaxAge: %s", err)
+	}
+	clock.Advance(10 * time.Minute)
+	err := hs256.Validate(encoded)
+	if err == nil || !strings.Contains(err.Error(), ErrTokenTooOld.Error()) {
+		t.Errorf("jwt.TestJWT_SetMaxAge: %v doesn't contain %v", err, ErrTokenTooOld)
+	}
+
+	withoutIat := &Claims{claims: map[string]interface{}{"sub": "user"}}
+	encoded, _ = hs256.Encode(withoutIat)
+	err = hs256.Validate(encoded)
+	if err == nil || !strings.Contains(err.Error(), ErrTokenMissingIssuedAt.Error()) {
+		t.Errorf("jwt.TestJWT_SetMaxAge: %v doesn't contain %v", err, ErrTokenMissingIssuedAt)
 	}
 }
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationCheck identifies a check performed when a token is validated.
+// Checks are bit flags, so a set of them is combined with |.
+type ValidationCheck uint32
+
+const (
+	CheckAlgorithm ValidationCheck = 1 << iota
+	CheckSignature
+	CheckExpiresAt
+	CheckNotBefore
+	CheckIssuedAt
+	CheckAudience
+	CheckIssuer
+	CheckSubject
+	CheckID
+	CheckCritical
+	CheckKey
+)
+
+var validationCheckNames = []struct {
+	check ValidationCheck
+	name  string
+}{
+	{check: CheckAlgorithm, name: "alg"},
+	{check: CheckCritical, name: "crit"},
+	{check: CheckKey, name: "key"},
+	{check: CheckSignature, name: "signature"},
+	{check: CheckExpiresAt, name: "exp"},
+	{check: CheckNotBefore, name: "nbf"},
+	{check: CheckIssuedAt, name: "iat"},
+	{check: CheckAudience, name: "aud"},
+	{check: CheckIssuer, name: "iss"},
+	{check: CheckSubject, name: "sub"},
+	{check: CheckID, name: "jti"},
+}
+
+// String returns the names of the checks separated by commas.
+func (check ValidationCheck) String() string {
+	var names []string
+	for _, c := range validationCheckNames {
+		if check&c.check != 0 {
+			names = append(names, c.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ValidationError is returned when a decoded token is invalid.
+//
+// It wraps the errors of the failed checks, so the sentinel errors are found
+// by errors.Is, e.g. errors.Is(err, ErrTokenHasExpired), and the error itself
+// is retrieved by errors.As to find out which checks failed.
+type ValidationError struct {
+	// Checks is the set of the checks which failed.
+	Checks ValidationCheck
+
+	// Errors holds an error for each failed check.
+	Errors []error
+}
+
+// newValidationError returns a ValidationError for a single failed check.
+func newValidationError(check ValidationCheck, err error) *ValidationError {
+	e := &ValidationError{}
+	e.add(check, err)
+	return e
+}
+
+// add records the failure of the check.
+func (e *ValidationError) add(check ValidationCheck, err error) {
+	e.Checks |= check
+	e.Errors = append(e.Errors, fmt.Errorf("failed to validate %s: %w", check, err))
+}
+
+// Has returns if the given check failed.
+func (e *ValidationError) Has(check ValidationCheck) bool {
+	return e.Checks&check != 0
+}
+
+// Error returns the messages of the failed checks separated by semicolons.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors of the failed checks.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Yuriy Lisovskiy
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidationCheck_String(t *testing.T) {
+	if actual := CheckExpiresAt.String(); actual != "exp" {
+		t.Errorf("jwt.TestValidationCheck_String: %s != %s", actual, "exp")
+	}
+	if actual := (CheckSignature | CheckAudience).String(); actual != "signature, aud" {
+		t.Errorf("jwt.TestValidationCheck_String: %s != %s", actual, "signature, aud")
+	}
+}
+
+func TestValidationError_Is(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	hs256.SetClock(clock)
+	claims := hs256.NewClaims()
+	claims.SetTime("exp", clock.Now().Add(time.Minute))
+	encoded, _ := hs256.Encode(claims)
+	clock.Advance(time.Hour)
+
+	err := hs256.Validate(encoded)
+	if !errors.Is(err, ErrTokenHasExpired) {
+		t.Errorf("jwt.TestValidationError_Is: %v is not %v", err, ErrTokenHasExpired)
+	}
+	if errors.Is(err, ErrTokenInvalidSignature) {
+		t.Errorf("jwt.TestValidationError_Is: %v is %v", err, ErrTokenInvalidSignature)
+	}
+	if err.Error() != "failed to validate exp: token has expired" {
+		t.Errorf("jwt.TestValidationError_Is: invalid message: %s", err)
+	}
+
+	other := HmacSha256("another-secret-key")
+	err = other.Validate(encoded)
+	if !errors.Is(err, ErrTokenInvalidSignature) {
+		t.Errorf("jwt.TestValidationError_Is: %v is not %v", err, ErrTokenInvalidSignature)
+	}
+}
+
+func TestValidationError_As(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	hs256.SetAudience("api")
+	claims := NewClaims()
+	claims.Set("aud", "web")
+	encoded, _ := hs256.Encode(claims)
+
+	var validationErr *ValidationError
+	if !errors.As(hs256.Validate(encoded), &validationErr) {
+		t.Fatalf("jwt.TestValidationError_As: error is not a *ValidationError")
+	}
+	if !validationErr.Has(CheckAudience) || validationErr.Has(CheckExpiresAt) {
+		t.Errorf("jwt.TestValidationError_As: invalid checks: %s", validationErr.Checks)
+	}
+
+	if _, err := hs256.DecodeAndValidate("malformed"); errors.As(err, &validationErr) || err != ErrTokenIsMalformed {
+		t.Errorf("jwt.TestValidationError_As: %v != %v", err, ErrTokenIsMalformed)
+	}
+}
+
+func TestJWT_SetReportAllErrors(t *testing.T) {
+	hs256 := HmacSha256("super-secret-key")
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	hs256.SetClock(clock)
+	hs256.SetAudience("api")
+	hs256.SetIssuer("auth")
+	claims := hs256.NewClaims()
+	claims.SetTime("exp", clock.Now().Add(-time.Minute))
+	claims.Set("aud", "web")
+	claims.Set("iss", "auth")
+	encoded, _ := hs256.Encode(claims)
+
+	var validationErr *ValidationError
+	if !errors.As(hs256.Validate(encoded), &validationErr) {
+		t.Fatalf("jwt.TestJWT_SetReportAllErrors: error is not a *ValidationError")
+	}
+	if validationErr.Checks != CheckExpiresAt {
+		t.Errorf("jwt.TestJWT_SetReportAllErrors: %s != %s", validationErr.Checks, CheckExpiresAt)
+	}
+
+	hs256.SetReportAllErrors(true)
+	err := hs256.Validate(encoded)
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("jwt.TestJWT_SetReportAllErrors: error is not a *ValidationError")
+	}
+	if validationErr.Checks != CheckExpiresAt|CheckAudience {
+		t.Errorf("jwt.TestJWT_SetReportAllErrors: %s != %s", validationErr.Checks, CheckExpiresAt|CheckAudience)
+	}
+	if !errors.Is(err, ErrTokenHasExpired) || !errors.Is(err, ErrTokenInvalidAudience) {
+		t.Errorf("jwt.TestJWT_SetReportAllErrors: %v doesn't wrap all failures", err)
+	}
+	expected := "failed to validate exp: token has expired; failed to validate aud: token has invalid audience"
+	if err.Error() != expected {
+		t.Errorf("jwt.TestJWT_SetReportAllErrors: %s != %s", err, expected)
+	}
+
+	other := HmacSha256("another-secret-key")
+	other.SetReportAllErrors(true)
+	if !errors.As(other.Validate(encoded), &validationErr) {
+		t.Fatalf("jwt.TestJWT_SetReportAllErrors: error is not a *ValidationError")
+	}
+	if validationErr.Checks != CheckSignature {
+		t.Errorf("jwt.TestJWT_SetReportAllErrors: %s != %s", validationErr.Checks, CheckSignature)
+	}
+}